@@ -1,6 +1,49 @@
 package adder
 
+import "github.com/ventive/go-mono-template/pkg/decimal"
+
+// CloudEvents types of the adder events
+const (
+	AddEventType  = "ventive.adder.add"
+	AddResultType = "ventive.adder.add.result"
+)
+
+// Versions of the schemas of the adder events, increased with their breaking changes.
+// The older versions of AddEvent are migrated by the upcasters of types.EventVersions.
+const (
+	AddEventVersion  = 1
+	AddResultVersion = 1
+)
+
 type AddEvent struct {
-	A float64 `mapstructure:"a" json:"a" validate:"number,required"`
-	B float64 `mapstructure:"b" json:"b" validate:"number,required"`
+	A float64 `mapstructure:"a" json:"a" validate:"number,required,finite"`
+	B float64 `mapstructure:"b" json:"b" validate:"number,required,finite"`
+}
+
+// AddResult is the response of the v2 add handler
+type AddResult struct {
+	Result float64 `mapstructure:"result" json:"result"`
+}
+
+// DecimalAddEvent is the AddEvent of the decimal arithmetic, its operands are JSON strings or numbers
+type DecimalAddEvent struct {
+	A *decimal.Decimal `mapstructure:"a" json:"a" validate:"required"`
+	B *decimal.Decimal `mapstructure:"b" json:"b" validate:"required"`
 }
+
+// DecimalAddResult is the response of the v2 add handler in decimal arithmetic, its result is a JSON string
+type DecimalAddResult struct {
+	Result *decimal.Decimal `mapstructure:"result" json:"result"`
+}
+
+// EventVersion godoc
+func (AddEvent) EventVersion() int { return AddEventVersion }
+
+// EventVersion godoc
+func (AddResult) EventVersion() int { return AddResultVersion }
+
+// EventVersion godoc
+func (DecimalAddEvent) EventVersion() int { return AddEventVersion }
+
+// EventVersion godoc
+func (DecimalAddResult) EventVersion() int { return AddResultVersion }
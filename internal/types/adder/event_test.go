@@ -36,3 +36,17 @@ func TestDecode(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeDecimal(t *testing.T) {
+	event, err := decoder.DecodeBytes[DecimalAddEvent]([]byte(`{"a":"0.1","b":0.2}`), decoder.Options{TagName: "json"})
+	if err != nil {
+		t.Fatalf("DecodeBytes returns error = %v", err)
+	}
+	if event.A.String() != "0.1" || event.B.String() != "0.2" {
+		t.Errorf("DecodeBytes returns a = %s, b = %s", event.A, event.B)
+	}
+
+	if _, err := decoder.DecodeBytes[DecimalAddEvent]([]byte(`{"a":"0.1"}`), decoder.Options{TagName: "json"}); err == nil {
+		t.Errorf("DecodeBytes returns no error without b")
+	}
+}
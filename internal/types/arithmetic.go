@@ -0,0 +1,44 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// ArithmeticHeader selects the arithmetic of a request, the one of the service config when not set
+const ArithmeticHeader = "arithmetic"
+
+// Arithmetic of the operations of the services
+type Arithmetic string
+
+const (
+	// ArithmeticFloat computes with float64 numbers and returns JSON numbers
+	ArithmeticFloat Arithmetic = "float"
+	// ArithmeticDecimal computes with arbitrary-precision decimals, read from JSON strings or numbers,
+	// and returns JSON strings
+	ArithmeticDecimal Arithmetic = "decimal"
+)
+
+// ErrUnknownArithmetic is returned for the arithmetics other than float or decimal
+var ErrUnknownArithmetic = errors.New("unknown arithmetic")
+
+// ParseArithmetic returns the Arithmetic named s
+func ParseArithmetic(s string) (Arithmetic, error) {
+	switch a := Arithmetic(s); a {
+	case ArithmeticFloat, ArithmeticDecimal:
+		return a, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownArithmetic, s)
+	}
+}
+
+// ArithmeticOf returns the arithmetic selected by the ArithmeticHeader of msg, fallback when not set
+func ArithmeticOf(msg *nats.Msg, fallback Arithmetic) (Arithmetic, error) {
+	if h := msg.Header.Get(ArithmeticHeader); h != "" {
+		return ParseArithmetic(h)
+	}
+
+	return fallback, nil
+}
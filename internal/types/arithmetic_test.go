@@ -0,0 +1,34 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArithmeticOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    Arithmetic
+		wantErr error
+	}{
+		{"fallback", "", ArithmeticFloat, nil},
+		{"decimal", "decimal", ArithmeticDecimal, nil},
+		{"float", "float", ArithmeticFloat, nil},
+		{"unknown", "fixed", "", ErrUnknownArithmetic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := nats.NewMsg("in")
+			if tt.header != "" {
+				msg.Header.Set(ArithmeticHeader, tt.header)
+			}
+
+			got, err := ArithmeticOf(msg, ArithmeticFloat)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+)
+
+// CloudEvents 1.0 envelope attributes and their NATS binding
+const (
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsContentType is the content type of the structured mode messages
+	CloudEventsContentType = "application/cloudevents+json"
+	// JSONContentType is the content type of the data of the events
+	JSONContentType = "application/json"
+	// ContentTypeHeader holds the content type of the messages
+	ContentTypeHeader = "content-type"
+	// CloudEventsHeaderPrefix prefixes the headers of the attributes in binary mode, e.g. ce-id
+	CloudEventsHeaderPrefix = "ce-"
+	// ErrorEventType is the type of the events published to the errors subject
+	ErrorEventType = "ventive.error"
+)
+
+// CloudEventsMode is the way the envelope is carried by the NATS messages
+type CloudEventsMode string
+
+const (
+	// CloudEventsStructured messages carry the envelope and the data as a JSON body
+	CloudEventsStructured CloudEventsMode = "structured"
+	// CloudEventsBinary messages carry the attributes as ce-* headers and the data as body
+	CloudEventsBinary CloudEventsMode = "binary"
+)
+
+var (
+	// ErrNotCloudEvent is returned by ParseCloudEvent for the messages without envelope, e.g. InputEvent
+	ErrNotCloudEvent = errors.New("not a CloudEvent")
+	// ErrInvalidCloudEvent is returned for the envelopes missing a required attribute
+	ErrInvalidCloudEvent = errors.New("invalid CloudEvent")
+	// ErrUnknownCloudEventsMode is returned for the modes other than structured or binary
+	ErrUnknownCloudEventsMode = errors.New("unknown CloudEvents mode")
+)
+
+// CloudEvent is a CloudEvents 1.0 envelope with JSON data.
+// CausationID is an extension attribute holding the id of the event which caused this one,
+// DataVersion the one holding the version of the schema of the data, see Versioned.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time,omitzero"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	CausationID     string          `json:"causationid,omitempty"`
+	DataVersion     int             `json:"dataversion,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+
+	// Mode the event was read in
+	Mode CloudEventsMode `json:"-"`
+}
+
+// NewCloudEvent returns an event of eventType from source, with a new id, the current time and data encoded in JSON.
+// Its DataVersion is the one of the Versioned data.
+func NewCloudEvent(source, eventType string, data interface{}) (CloudEvent, error) {
+	event := CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              nuid.Next(),
+		Source:          source,
+		Type:            eventType,
+		Time:            time.Now().UTC(),
+		DataContentType: JSONContentType,
+	}
+	if versioned, ok := data.(Versioned); ok {
+		event.DataVersion = versioned.EventVersion()
+	}
+
+	var err error
+	event.Data, err = json.Marshal(data)
+
+	return event, err
+}
+
+// Validate checks the required attributes of e
+func (e CloudEvent) Validate() error {
+	if e.SpecVersion != CloudEventsSpecVersion {
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalidCloudEvent, e.SpecVersion)
+	}
+	for _, attribute := range [][2]string{{"id", e.ID}, {"source", e.Source}, {"type", e.Type}} {
+		if attribute[1] == "" {
+			return fmt.Errorf("%w: missing %s", ErrInvalidCloudEvent, attribute[0])
+		}
+	}
+	if e.DataVersion < 0 {
+		return fmt.Errorf("%w: negative dataversion %d", ErrInvalidCloudEvent, e.DataVersion)
+	}
+
+	return nil
+}
+
+// ParseCloudEvent reads the event of msg, in binary mode when it has a ce-specversion header, else in structured mode.
+// The messages without envelope return ErrNotCloudEvent.
+func ParseCloudEvent(msg *nats.Msg) (CloudEvent, error) {
+	var event CloudEvent
+	if msg.Header.Get(CloudEventsHeaderPrefix+"specversion") != "" {
+		event = CloudEvent{
+			SpecVersion:     msg.Header.Get(CloudEventsHeaderPrefix + "specversion"),
+			ID:              msg.Header.Get(CloudEventsHeaderPrefix + "id"),
+			Source:          msg.Header.Get(CloudEventsHeaderPrefix + "source"),
+			Type:            msg.Header.Get(CloudEventsHeaderPrefix + "type"),
+			Subject:         msg.Header.Get(CloudEventsHeaderPrefix + "subject"),
+			DataContentType: msg.Header.Get(ContentTypeHeader),
+			DataSchema:      msg.Header.Get(CloudEventsHeaderPrefix + "dataschema"),
+			CausationID:     msg.Header.Get(CloudEventsHeaderPrefix + "causationid"),
+			Data:            msg.Data,
+			Mode:            CloudEventsBinary,
+		}
+		if t := msg.Header.Get(CloudEventsHeaderPrefix + "time"); t != "" {
+			var err error
+			if event.Time, err = time.Parse(time.RFC3339Nano, t); err != nil {
+				return event, fmt.Errorf("%w: time: %w", ErrInvalidCloudEvent, err)
+			}
+		}
+		if v := msg.Header.Get(CloudEventsHeaderPrefix + "dataversion"); v != "" {
+			var err error
+			if event.DataVersion, err = strconv.Atoi(v); err != nil {
+				return event, fmt.Errorf("%w: dataversion: %w", ErrInvalidCloudEvent, err)
+			}
+		}
+
+		return event, event.Validate()
+	}
+
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		return event, err
+	}
+	if event.SpecVersion == "" {
+		return event, ErrNotCloudEvent
+	}
+	event.Mode = CloudEventsStructured
+
+	return event, event.Validate()
+}
+
+// CloudEventData returns the JSON data of msg: its body in binary mode, else the data of its JSON body,
+// in structured mode or an InputEvent
+func CloudEventData(msg *nats.Msg) ([]byte, error) {
+	data, _, err := versionedData(msg)
+
+	return data, err
+}
+
+// versionedData returns the JSON data of msg as CloudEventData and its dataversion, 0 when not set
+func versionedData(msg *nats.Msg) ([]byte, int, error) {
+	if msg.Header.Get(CloudEventsHeaderPrefix+"specversion") != "" {
+		version := 0
+		if v := msg.Header.Get(CloudEventsHeaderPrefix + "dataversion"); v != "" {
+			var err error
+			if version, err = strconv.Atoi(v); err != nil {
+				return nil, 0, fmt.Errorf("%w: dataversion: %w", ErrInvalidCloudEvent, err)
+			}
+		}
+
+		return msg.Data, version, nil
+	}
+
+	var payload struct {
+		DataVersion int             `json:"dataversion"`
+		Data        json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(msg.Data, &payload); err != nil {
+		return nil, 0, err
+	}
+
+	return payload.Data, payload.DataVersion, nil
+}
+
+// Encode writes e to msg in mode, replacing its body and its ce-* and content-type headers
+func (e CloudEvent) Encode(msg *nats.Msg, mode CloudEventsMode) error {
+	if mode != CloudEventsStructured && mode != CloudEventsBinary {
+		return fmt.Errorf("%w: %q", ErrUnknownCloudEventsMode, mode)
+	}
+	if msg.Header == nil {
+		msg.Header = nats.Header{}
+	}
+	for k := range msg.Header {
+		if strings.HasPrefix(strings.ToLower(k), CloudEventsHeaderPrefix) || strings.EqualFold(k, ContentTypeHeader) {
+			msg.Header.Del(k)
+		}
+	}
+
+	if mode == CloudEventsStructured {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		msg.Data = data
+		msg.Header.Set(ContentTypeHeader, CloudEventsContentType)
+
+		return nil
+	}
+
+	attributes := map[string]string{
+		"specversion": e.SpecVersion,
+		"id":          e.ID,
+		"source":      e.Source,
+		"type":        e.Type,
+		"subject":     e.Subject,
+		"dataschema":  e.DataSchema,
+		"causationid": e.CausationID,
+	}
+	if !e.Time.IsZero() {
+		attributes["time"] = e.Time.Format(time.RFC3339Nano)
+	}
+	if e.DataVersion != 0 {
+		attributes["dataversion"] = strconv.Itoa(e.DataVersion)
+	}
+	for name, value := range attributes {
+		if value != "" {
+			msg.Header.Set(CloudEventsHeaderPrefix+name, value)
+		}
+	}
+	if e.DataContentType != "" {
+		msg.Header.Set(ContentTypeHeader, e.DataContentType)
+	}
+	msg.Data = e.Data
+
+	return nil
+}
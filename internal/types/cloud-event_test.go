@@ -0,0 +1,125 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudEvent(t *testing.T) {
+	event, err := NewCloudEvent("adder", "ventive.adder.add.result", map[string]float64{"result": 3})
+	assert.Nil(t, err)
+	event.CausationID = "input-id"
+	event.DataVersion = 2
+	event.Time = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		mode CloudEventsMode
+	}{
+		{"structured", CloudEventsStructured},
+		{"binary", CloudEventsBinary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := nats.NewMsg("out")
+			msg.Header.Set("ce-type", "input.type")
+			msg.Header.Set("X-Request-Id", "1")
+			assert.Nil(t, event.Encode(msg, tt.mode))
+
+			got, err := ParseCloudEvent(msg)
+			assert.Nil(t, err)
+			want := event
+			want.Mode = tt.mode
+			assert.Equal(t, want, got)
+			assert.Equal(t, "1", msg.Header.Get("X-Request-Id"))
+
+			data, err := CloudEventData(msg)
+			assert.Nil(t, err)
+			assert.JSONEq(t, `{"result":3}`, string(data))
+		})
+	}
+
+	t.Run("structuredBody", func(t *testing.T) {
+		msg := nats.NewMsg("out")
+		assert.Nil(t, event.Encode(msg, CloudEventsStructured))
+		assert.Equal(t, CloudEventsContentType, msg.Header.Get(ContentTypeHeader))
+
+		var body map[string]interface{}
+		assert.Nil(t, json.Unmarshal(msg.Data, &body))
+		assert.Equal(t, map[string]interface{}{
+			"specversion":     "1.0",
+			"id":              event.ID,
+			"source":          "adder",
+			"type":            "ventive.adder.add.result",
+			"time":            "2024-01-02T03:04:05Z",
+			"datacontenttype": "application/json",
+			"causationid":     "input-id",
+			"dataversion":     float64(2),
+			"data":            map[string]interface{}{"result": float64(3)},
+		}, body)
+	})
+
+	t.Run("binaryHeaders", func(t *testing.T) {
+		msg := nats.NewMsg("out")
+		assert.Nil(t, event.Encode(msg, CloudEventsBinary))
+		assert.Equal(t, event.ID, msg.Header.Get("ce-id"))
+		assert.Equal(t, "input-id", msg.Header.Get("ce-causationid"))
+		assert.Equal(t, "2024-01-02T03:04:05Z", msg.Header.Get("ce-time"))
+		assert.Equal(t, "2", msg.Header.Get("ce-dataversion"))
+		assert.Equal(t, JSONContentType, msg.Header.Get(ContentTypeHeader))
+		assert.JSONEq(t, `{"result":3}`, string(msg.Data))
+	})
+
+	t.Run("unknownMode", func(t *testing.T) {
+		assert.ErrorIs(t, event.Encode(nats.NewMsg("out"), "xml"), ErrUnknownCloudEventsMode)
+	})
+}
+
+func TestParseCloudEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		headers map[string]string
+		wantErr error
+	}{
+		{"input event", `{"data":{"a":1}}`, nil, ErrNotCloudEvent},
+		{"structured", `{"specversion":"1.0","id":"1","source":"s","type":"t","data":{"a":1}}`, nil, nil},
+		{"structured missing type", `{"specversion":"1.0","id":"1","source":"s"}`, nil, ErrInvalidCloudEvent},
+		{"unsupported version", `{"specversion":"0.3","id":"1","source":"s","type":"t"}`, nil, ErrInvalidCloudEvent},
+		{"binary", `{"a":1}`, map[string]string{"ce-specversion": "1.0", "ce-id": "1", "ce-source": "s", "ce-type": "t"}, nil},
+		{"binary missing id", `{"a":1}`, map[string]string{"ce-specversion": "1.0", "ce-source": "s", "ce-type": "t"},
+			ErrInvalidCloudEvent},
+		{"binary invalid time", `{"a":1}`, map[string]string{"ce-specversion": "1.0", "ce-id": "1", "ce-source": "s",
+			"ce-type": "t", "ce-time": "yesterday"}, ErrInvalidCloudEvent},
+		{"binary invalid dataversion", `{"a":1}`, map[string]string{"ce-specversion": "1.0", "ce-id": "1", "ce-source": "s",
+			"ce-type": "t", "ce-dataversion": "v2"}, ErrInvalidCloudEvent},
+		{"negative dataversion", `{"specversion":"1.0","id":"1","source":"s","type":"t","dataversion":-1}`, nil,
+			ErrInvalidCloudEvent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := nats.NewMsg("in")
+			for k, v := range tt.headers {
+				msg.Header.Set(k, v)
+			}
+			msg.Data = []byte(tt.data)
+
+			_, err := ParseCloudEvent(msg)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("inputEventData", func(t *testing.T) {
+		msg := nats.NewMsg("in")
+		msg.Data = []byte(`{"data":{"a":1}}`)
+
+		data, err := CloudEventData(msg)
+		assert.Nil(t, err)
+		assert.JSONEq(t, `{"a":1}`, string(data))
+	})
+}
@@ -1,5 +1,8 @@
 package types
 
+// InputEvent is a message without CloudEvents envelope. DataVersion is the version of the schema of Data,
+// the first one when not set.
 type InputEvent struct {
-	Data map[string]interface{} `mapstructure:"data" json:"data"`
+	DataVersion int                    `mapstructure:"dataversion" json:"dataversion,omitempty"`
+	Data        map[string]interface{} `mapstructure:"data" json:"data"`
 }
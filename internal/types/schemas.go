@@ -0,0 +1,34 @@
+package types
+
+import (
+	"github.com/ventive/go-mono-template/internal/types/adder"
+	"github.com/ventive/go-mono-template/internal/types/subtractor"
+	"github.com/ventive/go-mono-template/pkg/jsonschema"
+)
+
+// SchemaBaseID prefixes the $id of the event schemas
+const SchemaBaseID = "urn:ventive:events:"
+
+// events are the types published to or consumed from the services, by schema name
+var events = map[string]interface{}{
+	"adder.AddEvent":                   adder.AddEvent{},
+	"adder.AddResult":                  adder.AddResult{},
+	"adder.DecimalAddEvent":            adder.DecimalAddEvent{},
+	"adder.DecimalAddResult":           adder.DecimalAddResult{},
+	"subtractor.SubtractEvent":         subtractor.SubtractEvent{},
+	"subtractor.SubtractResult":        subtractor.SubtractResult{},
+	"subtractor.DecimalSubtractEvent":  subtractor.DecimalSubtractEvent{},
+	"subtractor.DecimalSubtractResult": subtractor.DecimalSubtractResult{},
+}
+
+// Schemas returns the JSON schemas of the events
+func Schemas() (*jsonschema.Registry, error) {
+	registry := jsonschema.NewRegistry(SchemaBaseID)
+	for name, event := range events {
+		if err := registry.Register(name, event); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemas(t *testing.T) {
+	registry, err := Schemas()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"adder.AddEvent", "adder.AddResult", "adder.DecimalAddEvent", "adder.DecimalAddResult",
+		"subtractor.DecimalSubtractEvent", "subtractor.DecimalSubtractResult",
+		"subtractor.SubtractEvent", "subtractor.SubtractResult",
+	}, registry.Names())
+
+	s, err := registry.Get("adder.AddEvent")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, s.Required)
+	assert.Nil(t, s.ValidateJSON([]byte(`{"a":1,"b":2.5}`)))
+	assert.Error(t, s.ValidateJSON([]byte(`{"a":"1"}`)))
+
+	decimalEvent, err := registry.Get("adder.DecimalAddEvent")
+	assert.Nil(t, err)
+	assert.Nil(t, decimalEvent.ValidateJSON([]byte(`{"a":"0.1","b":0.2}`)))
+	assert.Error(t, decimalEvent.ValidateJSON([]byte(`{"a":"0.1"}`)))
+	assert.Error(t, decimalEvent.ValidateJSON([]byte(`{"a":true,"b":0.2}`)))
+}
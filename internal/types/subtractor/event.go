@@ -1,6 +1,49 @@
 package subtractor
 
+import "github.com/ventive/go-mono-template/pkg/decimal"
+
+// CloudEvents types of the subtractor events
+const (
+	SubtractEventType  = "ventive.subtractor.subtract"
+	SubtractResultType = "ventive.subtractor.subtract.result"
+)
+
+// Versions of the schemas of the subtractor events, increased with their breaking changes.
+// The older versions of SubtractEvent are migrated by the upcasters of types.EventVersions.
+const (
+	SubtractEventVersion  = 1
+	SubtractResultVersion = 1
+)
+
 type SubtractEvent struct {
-	A float64 `mapstructure:"a" json:"a" validate:"number,required"`
-	B float64 `mapstructure:"b" json:"b" validate:"number,required"`
+	A float64 `mapstructure:"a" json:"a" validate:"number,required,finite"`
+	B float64 `mapstructure:"b" json:"b" validate:"number,required,finite"`
+}
+
+// SubtractResult is the response of the v2 subtract handler
+type SubtractResult struct {
+	Result float64 `mapstructure:"result" json:"result"`
+}
+
+// DecimalSubtractEvent is the SubtractEvent of the decimal arithmetic, its operands are JSON strings or numbers
+type DecimalSubtractEvent struct {
+	A *decimal.Decimal `mapstructure:"a" json:"a" validate:"required"`
+	B *decimal.Decimal `mapstructure:"b" json:"b" validate:"required"`
 }
+
+// DecimalSubtractResult is the response of the v2 subtract handler in decimal arithmetic, its result is a JSON string
+type DecimalSubtractResult struct {
+	Result *decimal.Decimal `mapstructure:"result" json:"result"`
+}
+
+// EventVersion godoc
+func (SubtractEvent) EventVersion() int { return SubtractEventVersion }
+
+// EventVersion godoc
+func (SubtractResult) EventVersion() int { return SubtractResultVersion }
+
+// EventVersion godoc
+func (DecimalSubtractEvent) EventVersion() int { return SubtractEventVersion }
+
+// EventVersion godoc
+func (DecimalSubtractResult) EventVersion() int { return SubtractResultVersion }
@@ -0,0 +1,214 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// FirstEventVersion is the version of the events which are not Versioned, and of the data without dataversion
+const FirstEventVersion = 1
+
+var (
+	// ErrUnknownFutureVersion is returned for the data of a version newer than the current one of its event
+	ErrUnknownFutureVersion = errors.New("unknown future event version")
+	// ErrMissingUpcaster is returned for the older versions which cannot be migrated to the current one
+	ErrMissingUpcaster = errors.New("missing upcaster")
+	// ErrDuplicateUpcaster is returned when a version already has an upcaster
+	ErrDuplicateUpcaster = errors.New("duplicate upcaster")
+	// ErrUnknownEvent is returned for the events not registered
+	ErrUnknownEvent = errors.New("unknown event")
+	// ErrInvalidVersion is returned for the versions lower than FirstEventVersion
+	ErrInvalidVersion = errors.New("invalid event version")
+)
+
+// Versioned events declare the version of their schema, increased with its breaking changes
+type Versioned interface {
+	EventVersion() int
+}
+
+// EventVersion returns the version of the schema of event, FirstEventVersion when it is not Versioned
+func EventVersion(event interface{}) int {
+	if versioned, ok := event.(Versioned); ok {
+		return versioned.EventVersion()
+	}
+
+	return FirstEventVersion
+}
+
+// Upcaster migrates the data of a version of an event to the next version
+type Upcaster func(data map[string]interface{}) (map[string]interface{}, error)
+
+// upcasters migrate the data of the older versions of the events, by schema name then version migrated, e.g.
+//
+//	"adder.AddEvent": {1: func(data map[string]interface{}) (map[string]interface{}, error) {...}},
+var upcasters = map[string]map[int]Upcaster{}
+
+type eventVersions struct {
+	current   int
+	upcasters map[int]Upcaster
+}
+
+// Versions holds the current version of the events and the upcasters of their older versions, by name
+type Versions struct {
+	mu     sync.RWMutex
+	events map[string]*eventVersions
+}
+
+// NewVersions returns an empty Versions
+func NewVersions() *Versions {
+	return &Versions{events: map[string]*eventVersions{}}
+}
+
+// EventVersions returns the versions of the events, with their upcasters
+func EventVersions() (*Versions, error) {
+	versions := NewVersions()
+	for name, event := range events {
+		if err := versions.Register(name, event); err != nil {
+			return nil, err
+		}
+	}
+	for name, byVersion := range upcasters {
+		for from, upcaster := range byVersion {
+			if err := versions.RegisterUpcaster(name, from, upcaster); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return versions, nil
+}
+
+// Register declares the current version of the event name, the EventVersion of event
+func (v *Versions) Register(name string, event interface{}) error {
+	current := EventVersion(event)
+	if current < FirstEventVersion {
+		return fmt.Errorf("%w: %s version %d", ErrInvalidVersion, name, current)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if e, ok := v.events[name]; ok {
+		e.current = current
+		return nil
+	}
+	v.events[name] = &eventVersions{current: current, upcasters: map[int]Upcaster{}}
+
+	return nil
+}
+
+// RegisterUpcaster adds the upcaster migrating the data of the version from of the event name to the version from+1
+func (v *Versions) RegisterUpcaster(name string, from int, upcaster Upcaster) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	e, ok := v.events[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEvent, name)
+	}
+	if from < FirstEventVersion || from >= e.current {
+		return fmt.Errorf("%w: %s upcaster from version %d, current is %d", ErrInvalidVersion, name, from, e.current)
+	}
+	if _, ok := e.upcasters[from]; ok {
+		return fmt.Errorf("%w: %s version %d", ErrDuplicateUpcaster, name, from)
+	}
+	e.upcasters[from] = upcaster
+
+	return nil
+}
+
+// Current returns the current version of the event name
+func (v *Versions) Current(name string) (int, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	e, ok := v.events[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownEvent, name)
+	}
+
+	return e.current, nil
+}
+
+// Upcast migrates data, of the version of the event name, to its current version with the upcasters of the
+// versions in between. The data of a version newer than the current one return ErrUnknownFutureVersion.
+func (v *Versions) Upcast(name string, version int, data map[string]interface{}) (map[string]interface{}, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	e, err := v.check(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	for from := version; from < e.current; from++ {
+		upcaster, ok := e.upcasters[from]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s version %d to %d", ErrMissingUpcaster, name, from, from+1)
+		}
+		if data, err = upcaster(data); err != nil {
+			return nil, fmt.Errorf("%s version %d to %d: %w", name, from, from+1, err)
+		}
+	}
+
+	return data, nil
+}
+
+// UpcastJSON migrates the JSON object data as Upcast, it is returned as is when of the current version
+func (v *Versions) UpcastJSON(name string, version int, data []byte) ([]byte, error) {
+	v.mu.RLock()
+	e, err := v.check(name, version)
+	v.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if version == e.current {
+		return data, nil
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	if object, err = v.Upcast(name, version, object); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(object)
+}
+
+// Data returns the JSON data of the messages as CloudEventData, migrated from their dataversion to the current
+// version of the event name
+func (v *Versions) Data(name string) func(msg *nats.Msg) ([]byte, error) {
+	return func(msg *nats.Msg) ([]byte, error) {
+		data, version, err := versionedData(msg)
+		if err != nil {
+			return nil, err
+		}
+		if version == 0 {
+			version = FirstEventVersion
+		}
+
+		return v.UpcastJSON(name, version, data)
+	}
+}
+
+// check returns the versions of the event name, if it can be migrated from version. v.mu must be held.
+func (v *Versions) check(name string, version int) (*eventVersions, error) {
+	e, ok := v.events[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEvent, name)
+	}
+	if version < FirstEventVersion {
+		return nil, fmt.Errorf("%w: %s version %d", ErrInvalidVersion, name, version)
+	}
+	if version > e.current {
+		return nil, fmt.Errorf("%w: %s version %d, current is %d", ErrUnknownFutureVersion, name, version, e.current)
+	}
+
+	return e, nil
+}
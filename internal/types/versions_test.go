@@ -0,0 +1,120 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+type versionedEvent struct {
+	Sum float64 `json:"sum"`
+}
+
+func (versionedEvent) EventVersion() int { return 3 }
+
+// testVersions migrates {"a":1} (v1) to {"x":1} (v2) to {"sum":1} (v3)
+func testVersions(t *testing.T) *Versions {
+	versions := NewVersions()
+	assert.Nil(t, versions.Register("test.Event", versionedEvent{}))
+	assert.Nil(t, versions.RegisterUpcaster("test.Event", 1, func(data map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"x": data["a"]}, nil
+	}))
+	assert.Nil(t, versions.RegisterUpcaster("test.Event", 2, func(data map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"sum": data["x"]}, nil
+	}))
+
+	return versions
+}
+
+func TestVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		data    string
+		want    string
+		wantErr error
+	}{
+		{"current", 3, `{"sum":1}`, `{"sum":1}`, nil},
+		{"previous", 2, `{"x":1}`, `{"sum":1}`, nil},
+		{"first", 1, `{"a":1}`, `{"sum":1}`, nil},
+		{"future", 4, `{"sum":1}`, "", ErrUnknownFutureVersion},
+		{"invalid", 0, `{"sum":1}`, "", ErrInvalidVersion},
+	}
+	versions := testVersions(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := versions.UpcastJSON("test.Event", tt.version, []byte(tt.data))
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.JSONEq(t, tt.want, string(got))
+			}
+		})
+	}
+
+	t.Run("futureMessage", func(t *testing.T) {
+		_, err := versions.Upcast("test.Event", 5, nil)
+		assert.EqualError(t, err, "unknown future event version: test.Event version 5, current is 3")
+	})
+
+	t.Run("missingUpcaster", func(t *testing.T) {
+		versions := NewVersions()
+		assert.Nil(t, versions.Register("test.Event", versionedEvent{}))
+		_, err := versions.Upcast("test.Event", 1, map[string]interface{}{})
+		assert.ErrorIs(t, err, ErrMissingUpcaster)
+	})
+
+	t.Run("registerUpcaster", func(t *testing.T) {
+		assert.ErrorIs(t, versions.RegisterUpcaster("test.Event", 1, nil), ErrDuplicateUpcaster)
+		assert.ErrorIs(t, versions.RegisterUpcaster("test.Event", 3, nil), ErrInvalidVersion)
+		assert.ErrorIs(t, versions.RegisterUpcaster("test.Other", 1, nil), ErrUnknownEvent)
+	})
+
+	t.Run("unknownEvent", func(t *testing.T) {
+		_, err := versions.Upcast("test.Other", 1, nil)
+		assert.ErrorIs(t, err, ErrUnknownEvent)
+	})
+}
+
+func TestVersionsData(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		headers map[string]string
+	}{
+		{"input event without version", `{"data":{"a":1}}`, nil},
+		{"input event", `{"dataversion":2,"data":{"x":1}}`, nil},
+		{"structured", `{"specversion":"1.0","id":"1","source":"s","type":"t","dataversion":2,"data":{"x":1}}`, nil},
+		{"binary", `{"x":1}`, map[string]string{"ce-specversion": "1.0", "ce-dataversion": "2"}},
+	}
+	data := testVersions(t).Data("test.Event")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := nats.NewMsg("in")
+			for k, v := range tt.headers {
+				msg.Header.Set(k, v)
+			}
+			msg.Data = []byte(tt.data)
+
+			got, err := data(msg)
+			assert.Nil(t, err)
+			assert.JSONEq(t, `{"sum":1}`, string(got))
+		})
+	}
+}
+
+func TestEventVersions(t *testing.T) {
+	versions, err := EventVersions()
+	assert.Nil(t, err)
+
+	for name, event := range events {
+		current, err := versions.Current(name)
+		assert.Nil(t, err)
+		assert.Equal(t, EventVersion(event), current)
+	}
+	assert.Equal(t, FirstEventVersion, EventVersion(struct{}{}))
+
+	event, err := NewCloudEvent("s", "t", versionedEvent{})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, event.DataVersion)
+}
@@ -0,0 +1,103 @@
+// Package auth verifies the JWT bearer tokens of the messages and authorizes
+// their principal against per-subject rules.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ventive/go-mono-template/pkg/nats"
+)
+
+// Config godoc
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Keys verifying the token signatures
+	Keys []KeyConfig `mapstructure:"keys"`
+	// JWKSFile is a JSON Web Key Set file holding more verification keys
+	JWKSFile string `mapstructure:"jwks_file"`
+	// Issuer expected in the iss claim, not checked when empty
+	Issuer string `mapstructure:"issuer"`
+	// Leeway tolerated on the exp, nbf and iat claims
+	Leeway time.Duration `mapstructure:"leeway"`
+	// Rules authorizing the principals, the first rule matching the subject applies.
+	// Messages on subjects matching no rule are rejected.
+	Rules []Rule `mapstructure:"rules"`
+}
+
+// KeyConfig godoc
+type KeyConfig struct {
+	// ID matched against the kid header of the tokens, optional
+	ID string `mapstructure:"id"`
+	// Algorithm is one of HS256, RS256 or EdDSA
+	Algorithm string `mapstructure:"algorithm"`
+	// File holds the shared secret for HS256 or the PEM public key for RS256 and EdDSA
+	File string `mapstructure:"file"`
+}
+
+// Rule godoc
+type Rule struct {
+	// Subject pattern, with the * and > wildcards
+	Subject string `mapstructure:"subject"`
+	// Audience accepted, the token must carry at least one of them when set
+	Audience []string `mapstructure:"audience"`
+	// Scopes all required from the token
+	Scopes []string `mapstructure:"scopes"`
+}
+
+// Principal is the authenticated caller of a message
+type Principal struct {
+	Subject  string
+	Issuer   string
+	Audience []string
+	Scopes   []string
+	Claims   map[string]any
+}
+
+// HasScope godoc
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Authorize checks p against the first rule matching subject
+func Authorize(rules []Rule, subject string, p *Principal) error {
+	for _, rule := range rules {
+		if nats.SubjectMatches(rule.Subject, subject) {
+			return rule.authorize(p)
+		}
+	}
+
+	return fmt.Errorf("%w: no rule for %s", ErrForbidden, subject)
+}
+
+func (r Rule) authorize(p *Principal) error {
+	if len(r.Audience) > 0 && !slices.ContainsFunc(p.Audience, func(aud string) bool {
+		return slices.Contains(r.Audience, aud)
+	}) {
+		return fmt.Errorf("%w: audience not accepted", ErrForbidden)
+	}
+
+	for _, scope := range r.Scopes {
+		if !p.HasScope(scope) {
+			return fmt.Errorf("%w: missing scope %s", ErrForbidden, scope)
+		}
+	}
+
+	return nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying p
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+
+	return p, ok
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "unit-tests-secret"
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func claims(extra jwt.MapClaims) jwt.MapClaims {
+	c := jwt.MapClaims{"sub": "unit-tests", "exp": time.Now().Add(time.Minute).Unix()}
+	for k, v := range extra {
+		c[k] = v
+	}
+
+	return c
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	edFile := writeFile(t, "ed.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	v, err := NewVerifier(Config{
+		Issuer: "issuer",
+		Keys: []KeyConfig{
+			{Algorithm: AlgorithmHS256, File: writeFile(t, "secret", []byte(secret+"\n"))},
+			{ID: "ed", Algorithm: AlgorithmEdDSA, File: edFile},
+		},
+	})
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name    string
+		token   string
+		want    *Principal
+		wantErr bool
+	}{
+		{
+			"hs256",
+			sign(t, jwt.SigningMethodHS256, []byte(secret), "", claims(jwt.MapClaims{"iss": "issuer", "aud": "adder", "scope": "add subtract"})),
+			&Principal{Subject: "unit-tests", Issuer: "issuer", Audience: []string{"adder"}, Scopes: []string{"add", "subtract"}},
+			false,
+		},
+		{
+			"eddsa",
+			sign(t, jwt.SigningMethodEdDSA, priv, "ed", claims(jwt.MapClaims{"iss": "issuer", "scp": []string{"add"}})),
+			&Principal{Subject: "unit-tests", Issuer: "issuer", Audience: nil, Scopes: []string{"add"}},
+			false,
+		},
+		{"wrong secret", sign(t, jwt.SigningMethodHS256, []byte("other"), "", claims(jwt.MapClaims{"iss": "issuer"})), nil, true},
+		{"wrong issuer", sign(t, jwt.SigningMethodHS256, []byte(secret), "", claims(jwt.MapClaims{"iss": "other"})), nil, true},
+		{"expired", sign(t, jwt.SigningMethodHS256, []byte(secret), "", jwt.MapClaims{"iss": "issuer", "exp": time.Now().Add(-time.Minute).Unix()}), nil, true},
+		{"no expiry", sign(t, jwt.SigningMethodHS256, []byte(secret), "", jwt.MapClaims{"iss": "issuer"}), nil, true},
+		{"unknown kid", sign(t, jwt.SigningMethodEdDSA, priv, "other", claims(jwt.MapClaims{"iss": "issuer"})), nil, true},
+		{"none algorithm", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", claims(jwt.MapClaims{"iss": "issuer"})), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Verify(tt.token)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrInvalidToken), err)
+				return
+			}
+			assert.Nil(t, err)
+			got.Claims = nil
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewVerifier_JWKS(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	set, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "OKP", "crv": "Ed25519", "kid": "ed", "x": base64.RawURLEncoding.EncodeToString(pub)},
+		{"kty": "oct", "kid": "hs", "k": base64.RawURLEncoding.EncodeToString([]byte(secret))},
+		{"kty": "oct", "use": "enc", "k": "ignored"},
+	}})
+
+	v, err := NewVerifier(Config{JWKSFile: writeFile(t, "jwks.json", set)})
+	assert.Nil(t, err)
+
+	_, err = v.Verify(sign(t, jwt.SigningMethodEdDSA, priv, "ed", claims(nil)))
+	assert.Nil(t, err)
+	_, err = v.Verify(sign(t, jwt.SigningMethodHS256, []byte(secret), "hs", claims(nil)))
+	assert.Nil(t, err)
+
+	_, err = NewVerifier(Config{})
+	assert.Equal(t, ErrNoKeys, err)
+	_, err = NewVerifier(Config{Keys: []KeyConfig{{Algorithm: "HS512", File: writeFile(t, "secret", []byte(secret))}}})
+	assert.True(t, errors.Is(err, ErrUnknownAlgorithm))
+}
+
+func TestAuthorize(t *testing.T) {
+	rules := []Rule{
+		{Subject: "add.admin.>", Scopes: []string{"admin"}},
+		{Subject: "add.>", Audience: []string{"adder", "calculator"}, Scopes: []string{"add"}},
+	}
+
+	tests := []struct {
+		name      string
+		subject   string
+		principal Principal
+		wantErr   bool
+	}{
+		{"allowed", "add.v1", Principal{Audience: []string{"calculator"}, Scopes: []string{"add"}}, false},
+		{"wrong audience", "add.v1", Principal{Audience: []string{"other"}, Scopes: []string{"add"}}, true},
+		{"missing scope", "add.v1", Principal{Audience: []string{"adder"}}, true},
+		{"first rule applies", "add.admin.reset", Principal{Audience: []string{"adder"}, Scopes: []string{"add"}}, true},
+		{"no rule", "subtract.v1", Principal{Audience: []string{"adder"}, Scopes: []string{"add"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Authorize(rules, tt.subject, &tt.principal)
+			if tt.wantErr != (err != nil) {
+				t.Errorf("Authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				assert.True(t, errors.Is(err, ErrForbidden))
+			}
+		})
+	}
+}
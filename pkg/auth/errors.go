@@ -0,0 +1,12 @@
+package auth
+
+import "errors"
+
+var (
+	ErrMissingToken     = errors.New("missing bearer token")
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrForbidden        = errors.New("forbidden")
+	ErrNoKeys           = errors.New("no verification keys configured")
+	ErrUnknownAlgorithm = errors.New("unknown signing algorithm")
+	ErrInvalidKey       = errors.New("invalid verification key")
+)
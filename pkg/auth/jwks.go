@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+}
+
+// loadJWKS reads the signature keys of a JSON Web Key Set file
+func loadJWKS(file string) ([]key, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidKey, file, err)
+	}
+
+	keys := make([]key, 0, len(set.Keys))
+	for _, j := range set.Keys {
+		if j.Use != "" && j.Use != "sig" {
+			continue
+		}
+		k, err := j.key()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: key %q: %w", ErrInvalidKey, file, j.Kid, err)
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+func (j jwk) key() (key, error) {
+	k := key{id: j.Kid, algorithm: j.Alg}
+
+	switch j.Kty {
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(j.K)
+		if err != nil {
+			return key{}, err
+		}
+		k.key = secret
+		if k.algorithm == "" {
+			k.algorithm = AlgorithmHS256
+		}
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return key{}, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return key{}, err
+		}
+		k.key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if k.algorithm == "" {
+			k.algorithm = AlgorithmRS256
+		}
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return key{}, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return key{}, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return key{}, fmt.Errorf("invalid Ed25519 key size %d", len(x))
+		}
+		k.key = ed25519.PublicKey(x)
+		if k.algorithm == "" {
+			k.algorithm = AlgorithmEdDSA
+		}
+	default:
+		return key{}, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+
+	return k, nil
+}
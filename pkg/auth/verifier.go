@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var algorithms = []string{AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA}
+
+type key struct {
+	id        string
+	algorithm string
+	key       any
+}
+
+// Verifier validates the signature and the registered claims of the tokens
+type Verifier struct {
+	keys   []key
+	parser *jwt.Parser
+}
+
+// NewVerifier loads the keys of cfg.
+// Tokens must be signed by one of them with its algorithm and must carry an exp claim.
+func NewVerifier(cfg Config) (*Verifier, error) {
+	v := &Verifier{}
+
+	for _, kc := range cfg.Keys {
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = append(v.keys, k)
+	}
+
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = append(v.keys, keys...)
+	}
+
+	if len(v.keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	v.parser = jwt.NewParser(options...)
+
+	return v, nil
+}
+
+// Verify parses token and returns its principal
+func (v *Verifier) Verify(token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.keyFunc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	p := &Principal{Claims: claims, Scopes: scopes(claims)}
+	p.Subject, _ = claims.GetSubject()
+	p.Issuer, _ = claims.GetIssuer()
+	p.Audience, _ = claims.GetAudience()
+
+	return p, nil
+}
+
+// keyFunc returns the keys of the token algorithm, restricted to its kid when set
+func (v *Verifier) keyFunc(token *jwt.Token) (any, error) {
+	alg := token.Method.Alg()
+	kid, _ := token.Header["kid"].(string)
+
+	set := jwt.VerificationKeySet{}
+	for _, k := range v.keys {
+		if k.algorithm == alg && (kid == "" || k.id == "" || k.id == kid) {
+			set.Keys = append(set.Keys, k.key)
+		}
+	}
+	if len(set.Keys) == 0 {
+		return nil, fmt.Errorf("no key for %s %q", alg, kid)
+	}
+
+	return set, nil
+}
+
+func loadKey(kc KeyConfig) (key, error) {
+	data, err := os.ReadFile(kc.File)
+	if err != nil {
+		return key{}, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+
+	k := key{id: kc.ID, algorithm: kc.Algorithm}
+	switch kc.Algorithm {
+	case AlgorithmHS256:
+		k.key = []byte(strings.TrimSpace(string(data)))
+	case AlgorithmRS256:
+		k.key, err = jwt.ParseRSAPublicKeyFromPEM(data)
+	case AlgorithmEdDSA:
+		k.key, err = jwt.ParseEdPublicKeyFromPEM(data)
+	default:
+		return key{}, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, kc.Algorithm)
+	}
+	if err != nil {
+		return key{}, fmt.Errorf("%w: %s: %w", ErrInvalidKey, kc.File, err)
+	}
+
+	return k, nil
+}
+
+// scopes reads the space separated scope claim or the scp claim
+func scopes(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+
+	switch scp := claims["scp"].(type) {
+	case string:
+		return strings.Fields(scp)
+	case []any:
+		s := make([]string, 0, len(scp))
+		for _, v := range scp {
+			if str, ok := v.(string); ok {
+				s = append(s, str)
+			}
+		}
+		return s
+	}
+
+	return nil
+}
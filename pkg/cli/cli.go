@@ -22,22 +22,48 @@ func Init(appID, appDescription string) {
 	}
 }
 
-// AddCommand adds a new command to CLI service
+// AddCommand adds a new command to CLI service.
+// A command without handlerFunc only groups the sub-commands added with AddSubCommand.
 func AddCommand(command, description string, handlerFunc CommandHandlerFunc) error {
 	if cmd == nil {
 		return ErrNotInitialized
 	}
 
-	cmd.AddCommand(&cobra.Command{
+	cmd.AddCommand(newCommand(command, description, handlerFunc))
+
+	return nil
+}
+
+// AddSubCommand adds a new command under the parent command, added first with AddCommand
+func AddSubCommand(parent, command, description string, handlerFunc CommandHandlerFunc) error {
+	if cmd == nil {
+		return ErrNotInitialized
+	}
+
+	for _, c := range cmd.Commands() {
+		if c.Name() == parent {
+			c.AddCommand(newCommand(command, description, handlerFunc))
+
+			return nil
+		}
+	}
+
+	return ErrCommandNotFound
+}
+
+func newCommand(command, description string, handlerFunc CommandHandlerFunc) *cobra.Command {
+	c := &cobra.Command{
 		Use:   command,
 		Short: description,
 		Long:  description,
-		Run: func(cmd *cobra.Command, _ []string) {
+	}
+	if handlerFunc != nil {
+		c.Run = func(cmd *cobra.Command, _ []string) {
 			handlerFunc(cmd.Context())
-		},
-	})
+		}
+	}
 
-	return nil
+	return c
 }
 
 // AssignStringFlag set a string flag to CLI service
@@ -45,6 +71,16 @@ func AssignStringFlag(target *string, name, defaultValue, description string) {
 	cmd.PersistentFlags().StringVar(target, name, defaultValue, description)
 }
 
+// AssignUint64Flag set an uint64 flag to CLI service
+func AssignUint64Flag(target *uint64, name string, defaultValue uint64, description string) {
+	cmd.PersistentFlags().Uint64Var(target, name, defaultValue, description)
+}
+
+// AssignIntFlag set an int flag to CLI service
+func AssignIntFlag(target *int, name string, defaultValue int, description string) {
+	cmd.PersistentFlags().IntVar(target, name, defaultValue, description)
+}
+
 // Run runs the CLI service with a context attached
 func Run(ctx context.Context) error {
 	return cmd.ExecuteContext(ctx)
@@ -79,6 +79,37 @@ func TestAddCommand(t *testing.T) {
 	})
 }
 
+func TestAddSubCommand(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		defer reset()
+		Init(testAppID, testAppDesc)
+
+		assert.Nil(t, AddCommand(testCmdName, testCmdDesc, nil))
+		err := AddSubCommand(testCmdName, "sub", testCmdDesc, func(_ context.Context) {
+			testCommandOutput = "sub"
+		})
+		assert.Nil(t, err)
+
+		cmd.SetArgs([]string{testCmdName, "sub"})
+		assert.Nil(t, cmd.Execute())
+		assert.Equal(t, "sub", testCommandOutput)
+	})
+
+	t.Run("returnsErrWhenParentNotFound", func(t *testing.T) {
+		defer reset()
+		Init(testAppID, testAppDesc)
+
+		actual := AddSubCommand(testCmdName, "sub", testCmdDesc, testCommandHandler)
+		assert.Equal(t, ErrCommandNotFound, actual)
+	})
+
+	t.Run("returnsErrWhenNotInitialized", func(t *testing.T) {
+		defer reset()
+		actual := AddSubCommand(testCmdName, "sub", testCmdDesc, testCommandHandler)
+		assert.Equal(t, ErrNotInitialized, actual)
+	})
+}
+
 func TestAssignStringFlag(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		defer reset()
@@ -2,5 +2,9 @@ package cli
 
 import "errors"
 
-// ErrNotInitialized is returned when cobra.Command is not initialized
-var ErrNotInitialized = errors.New("not initialized")
+var (
+	// ErrNotInitialized is returned when cobra.Command is not initialized
+	ErrNotInitialized = errors.New("not initialized")
+	// ErrCommandNotFound is returned when the parent of a sub-command is not found
+	ErrCommandNotFound = errors.New("command not found")
+)
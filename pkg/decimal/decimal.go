@@ -0,0 +1,109 @@
+package decimal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/ventive/go-mono-template/pkg/jsonschema"
+)
+
+const (
+	// DefaultPrecision is the number of significant digits of the results, the one of decimal128
+	DefaultPrecision = 34
+	// DefaultRounding is the rounding mode of the results, the banker's rounding
+	DefaultRounding = apd.RoundHalfEven
+)
+
+var (
+	// ErrInvalidDecimal is returned for the values which are not finite decimal numbers
+	ErrInvalidDecimal = errors.New("invalid decimal")
+	// ErrUnknownRounding is returned for the rounding modes unknown to apd
+	ErrUnknownRounding = errors.New("unknown rounding mode")
+)
+
+var roundings = []apd.Rounder{
+	apd.RoundDown, apd.RoundHalfUp, apd.RoundHalfEven, apd.RoundCeiling,
+	apd.RoundFloor, apd.RoundHalfDown, apd.RoundUp, apd.Round05Up,
+}
+
+// Decimal is an arbitrary-precision decimal, read from JSON strings or numbers and written as a JSON string
+type Decimal struct {
+	apd.Decimal
+}
+
+// New returns the Decimal of s, e.g. "0.1"
+func New(s string) (*Decimal, error) {
+	d := &Decimal{}
+	if err := d.set(s); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// UnmarshalJSON reads the JSON strings and numbers, without rounding
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var err error
+		if s, err = strconv.Unquote(s); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidDecimal, data)
+		}
+	}
+
+	return d.set(s)
+}
+
+// MarshalJSON writes d as a JSON string, without exponent
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// JSONSchema returns the schema of the values read by UnmarshalJSON, the strings or numbers
+func (Decimal) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{AnyOf: []*jsonschema.Schema{{Type: jsonschema.TypeString}, {Type: jsonschema.TypeNumber}}}
+}
+
+// String returns d without exponent, e.g. 1000 rather than 1E+3
+func (d Decimal) String() string {
+	return d.Text('f')
+}
+
+func (d *Decimal) set(s string) error {
+	if _, _, err := d.SetString(s); err != nil || d.Form != apd.Finite {
+		return fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	return nil
+}
+
+// Config godoc
+type Config struct {
+	// Precision is the number of significant digits of the results, DefaultPrecision when 0
+	Precision uint32 `mapstructure:"precision"`
+	// Rounding of the results with more digits than Precision: down, half_up, half_even, ceiling, floor,
+	// half_down, up or 05up. DefaultRounding when empty.
+	Rounding string `mapstructure:"rounding"`
+}
+
+// Context returns the apd context computing with the precision and rounding of c
+func (c Config) Context() (*apd.Context, error) {
+	ctx := apd.BaseContext.WithPrecision(DefaultPrecision)
+	if c.Precision > 0 {
+		ctx.Precision = c.Precision
+	}
+
+	ctx.Rounding = DefaultRounding
+	if c.Rounding != "" {
+		ctx.Rounding = apd.Rounder(c.Rounding)
+		if !slices.Contains(roundings, ctx.Rounding) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownRounding, c.Rounding)
+		}
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,110 @@
+package decimal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/ventive/go-mono-template/pkg/decoder"
+)
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr error
+	}{
+		{"string", `"0.1"`, `"0.1"`, nil},
+		{"number", `0.2`, `"0.2"`, nil},
+		{"exponent", `1E+3`, `"1000"`, nil},
+		{"many digits", `"12345678901234567890.123456789"`, `"12345678901234567890.123456789"`, nil},
+		{"not a number", `"one"`, "", ErrInvalidDecimal},
+		{"not finite", `"Infinity"`, "", ErrInvalidDecimal},
+		{"nan", `"NaN"`, "", ErrInvalidDecimal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Decimal
+			err := json.Unmarshal([]byte(tt.data), &d)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				return
+			}
+
+			got, err := json.Marshal(d)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	a, err := New("0.1")
+	assert.Nil(t, err)
+	b, err := New("0.2")
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name string
+		cfg  Config
+		op   func(ctx *apd.Context, d *Decimal) error
+		want string
+	}{
+		{"exact sum", Config{}, func(ctx *apd.Context, d *Decimal) error {
+			_, err := ctx.Add(&d.Decimal, &a.Decimal, &b.Decimal)
+			return err
+		}, "0.3"},
+		{"default precision", Config{}, func(ctx *apd.Context, d *Decimal) error {
+			_, err := ctx.Quo(&d.Decimal, apd.New(1, 0), apd.New(3, 0))
+			return err
+		}, "0.3333333333333333333333333333333333"},
+		{"precision and rounding", Config{Precision: 2, Rounding: "up"}, func(ctx *apd.Context, d *Decimal) error {
+			_, err := ctx.Add(&d.Decimal, &b.Decimal, apd.New(1231, -2))
+			return err
+		}, "13"},
+		{"half even", Config{Precision: 1}, func(ctx *apd.Context, d *Decimal) error {
+			_, err := ctx.Round(&d.Decimal, apd.New(25, -1))
+			return err
+		}, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := tt.cfg.Context()
+			assert.Nil(t, err)
+
+			var d Decimal
+			assert.Nil(t, tt.op(ctx, &d))
+			assert.Equal(t, tt.want, d.String())
+		})
+	}
+
+	t.Run("unknownRounding", func(t *testing.T) {
+		_, err := Config{Rounding: "nearest"}.Context()
+		assert.ErrorIs(t, err, ErrUnknownRounding)
+	})
+}
+
+func TestDecodeHook(t *testing.T) {
+	type event struct {
+		A *Decimal `mapstructure:"a"`
+		B *Decimal `mapstructure:"b"`
+		C Decimal  `mapstructure:"c"`
+	}
+
+	var got event
+	_, err := decoder.DecodeWith(map[string]interface{}{"a": "0.1", "b": 0.2, "c": 3}, &got, decoder.Options{
+		Hooks: []mapstructure.DecodeHookFunc{DecodeHook()},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1", got.A.String())
+	assert.Equal(t, "0.2", got.B.String())
+	assert.Equal(t, "3", got.C.String())
+
+	_, err = decoder.DecodeWith(map[string]interface{}{"a": "one"}, &got, decoder.Options{
+		Hooks: []mapstructure.DecodeHookFunc{DecodeHook()},
+	})
+	assert.ErrorContains(t, err, "invalid decimal")
+}
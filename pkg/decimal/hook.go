@@ -0,0 +1,36 @@
+package decimal
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+var decimalType = reflect.TypeOf(Decimal{})
+
+// DecodeHook decodes the strings, json.Number and numbers into Decimal fields, or pointers to them,
+// for the decoders reading an intermediate map. The strings keep the digits lost by the float64 numbers.
+func DecodeHook() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if to != decimalType {
+			return data, nil
+		}
+
+		switch v := data.(type) {
+		case string:
+			return New(v)
+		case json.Number:
+			return New(v.String())
+		case float32, float64:
+			return New(strconv.FormatFloat(reflect.ValueOf(v).Float(), 'f', -1, 64))
+		case int, int8, int16, int32, int64:
+			return New(strconv.FormatInt(reflect.ValueOf(v).Int(), 10))
+		case uint, uint8, uint16, uint32, uint64:
+			return New(strconv.FormatUint(reflect.ValueOf(v).Uint(), 10))
+		default:
+			return data, nil
+		}
+	}
+}
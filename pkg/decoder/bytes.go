@@ -0,0 +1,73 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const unknownFieldPrefix = "json: unknown field "
+
+// DecodeBytes decodes the JSON object data into a T and validates it, like DecodeWith. With the TagName json,
+// it decodes in a single pass over data: the fields of T are read by encoding/json, with their json tags.
+//
+// The options that encoding/json cannot apply, WeaklyTyped, Hooks or a TagName other than json, including
+// the DefaultTagName of an empty one, decode data into a map first, then into the T with DecodeWith. In Strict mode, the first unknown key
+// is returned as an UnusedKeysError.
+func DecodeBytes[T any](data []byte, opts Options) (T, error) {
+	var event T
+	if opts.DecodesMap() {
+		var m map[string]interface{}
+		if err := json.Unmarshal(data, &m); err != nil {
+			return event, err
+		}
+		_, err := DecodeWith(m, &event, opts)
+
+		return event, err
+	}
+
+	v := opts.Validator
+	if v == nil {
+		v = defaultValidator
+	}
+	if _, err := v.translator(opts.Locale); err != nil {
+		return event, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if opts.Strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&event); err != nil {
+		return event, unknownFieldError(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return event, errors.New("invalid character after top-level value")
+	}
+
+	return event, v.structWith(&event, "json", opts.Locale)
+}
+
+// DecodesMap reports whether DecodeBytes decodes the payloads into a map first, for the options
+// that encoding/json cannot apply
+func (o Options) DecodesMap() bool {
+	return o.WeaklyTyped || len(o.Hooks) > 0 || o.TagName != "json"
+}
+
+// unknownFieldError returns the unknown field errors of encoding/json as UnusedKeysError
+func unknownFieldError(err error) error {
+	field, found := strings.CutPrefix(err.Error(), unknownFieldPrefix)
+	if !found {
+		return err
+	}
+
+	key, unquoteErr := strconv.Unquote(field)
+	if unquoteErr != nil {
+		return err
+	}
+
+	return &UnusedKeysError{Keys: []string{key}}
+}
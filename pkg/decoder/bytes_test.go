@@ -0,0 +1,86 @@
+package decoder
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bytesEvent struct {
+	A      float64 `mapstructure:"a" json:"a" validate:"required,finite"`
+	B      float64 `mapstructure:"b" json:"b" validate:"gte=0"`
+	Nested nested  `mapstructure:"nested" json:"nested"`
+}
+
+func TestDecodeBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		opts    Options
+		want    bytesEvent
+		wantErr error
+	}{
+		{"valid", `{"a":1.5,"b":2,"nested":{"full_name":"x"}}`, Options{TagName: "json"},
+			bytesEvent{A: 1.5, B: 2, Nested: nested{Name: "x"}}, nil},
+		{"unknown keys ignored", `{"a":1,"c":2}`, Options{TagName: "json"}, bytesEvent{A: 1}, nil},
+		{"unknown keys rejected when strict", `{"a":1,"c":2}`, Options{Strict: true, TagName: "json"}, bytesEvent{A: 1},
+			&UnusedKeysError{Keys: []string{"c"}}},
+		{"invalid", `{"b":-1}`, Options{TagName: "json"}, bytesEvent{B: -1}, ValidationErrors{
+			{Field: "a", Tag: "required", Message: "a is a required field"},
+			{Field: "b", Tag: "gte", Param: "0", Message: "b must be 0 or greater"},
+		}},
+		{"weakly typed", `{"a":"1","b":"2"}`, Options{WeaklyTyped: true}, bytesEvent{A: 1, B: 2}, nil},
+		{"mapstructure tags", `{"a":1,"nested":{"name":"x"}}`, Options{TagName: DefaultTagName},
+			bytesEvent{A: 1, Nested: nested{Name: "x"}}, nil},
+		{"default tag name", `{"a":1,"nested":{"name":"x"}}`, Options{},
+			bytesEvent{A: 1, Nested: nested{Name: "x"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeBytes[bytesEvent]([]byte(tt.data), tt.opts)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("wrongType", func(t *testing.T) {
+		_, err := DecodeBytes[bytesEvent]([]byte(`{"a":"1"}`), Options{TagName: "json"})
+		var typeErr *json.UnmarshalTypeError
+		assert.True(t, errors.As(err, &typeErr))
+		assert.Equal(t, "a", typeErr.Field)
+	})
+
+	t.Run("trailingData", func(t *testing.T) {
+		_, err := DecodeBytes[bytesEvent]([]byte(`{"a":1} {}`), Options{TagName: "json"})
+		assert.Error(t, err)
+	})
+}
+
+var benchmarkPayload = []byte(`{"a":10.5,"b":11.25,"nested":{"full_name":"benchmark"}}`)
+
+// BenchmarkDecodeMap is the path of the handlers before DecodeBytes: to a map, then to the struct
+func BenchmarkDecodeMap(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var m map[string]interface{}
+		if err := json.Unmarshal(benchmarkPayload, &m); err != nil {
+			b.Fatal(err)
+		}
+		var e bytesEvent
+		if err := Decode(m, &e); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeBytes(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeBytes[bytesEvent](benchmarkPayload, Options{TagName: "json"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
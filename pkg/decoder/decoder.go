@@ -1,21 +1,106 @@
 package decoder
 
 import (
-	"github.com/go-playground/validator/v10"
+	"slices"
+	"strings"
+
 	"github.com/mitchellh/mapstructure"
 )
 
-var validate = validator.New()
+// DefaultTagName is the struct tag naming the keys of the fields when Options.TagName is not set
+const DefaultTagName = "mapstructure"
+
+// Options of DecodeWith. The zero value decodes the same way as Decode.
+type Options struct {
+	// Strict rejects the data with keys matching no field of the event, at any depth
+	Strict bool `mapstructure:"strict"`
+	// WeaklyTyped converts the values to the type of their field, e.g. "1" to 1, 1 to true or "1" to []string{"1"}
+	WeaklyTyped bool `mapstructure:"weakly_typed"`
+	// TagName is the struct tag naming the keys of the fields, DefaultTagName when not set
+	TagName string `mapstructure:"tag_name"`
+	// Hooks convert the values before they are decoded, in order, see TimeHook, DurationHook,
+	// BigNumberHook and EnumHook
+	Hooks []mapstructure.DecodeHookFunc `mapstructure:"-"`
+	// Locale of the messages of the ValidationErrors, DefaultLocale when not set
+	Locale string `mapstructure:"locale"`
+	// Validator validates the decoded events, the one of NewValidator, shared by the callers, when nil
+	Validator *Validator `mapstructure:"-"`
+}
+
+// Metadata reports how the keys of the data matched the fields of the event.
+// The nested keys and fields are named with their path, e.g. "parent.child".
+type Metadata struct {
+	// Keys decoded into a field
+	Keys []string
+	// Unused keys, matching no field
+	Unused []string
+	// Unset fields, matching no key
+	Unset []string
+}
+
+// UnusedKeysError is returned in strict mode when keys of the data match no field
+type UnusedKeysError struct {
+	Keys []string
+}
+
+func (e *UnusedKeysError) Error() string {
+	return "unknown keys: " + strings.Join(e.Keys, ", ")
+}
 
 // Decode takes a map and translate it to a struct.
 // Validates the struct using go-playground/validator pkg.
 //
 // event must be a pointer to a struct with "validate" tags attached
 // "validate" tags are optional. If not provided, struct will be always valid.
+// The failed validations are returned as ValidationErrors.
 func Decode(data map[string]interface{}, event interface{}) error {
-	err := mapstructure.Decode(data, event)
+	_, err := DecodeWith(data, event, Options{})
+
+	return err
+}
+
+// DecodeWith decodes data into event like Decode, with opts, and returns how the keys of data matched
+// the fields of event. The metadata is returned with the decoding and validation errors.
+func DecodeWith(data map[string]interface{}, event interface{}, opts Options) (Metadata, error) {
+	v := opts.Validator
+	if v == nil {
+		v = defaultValidator
+	}
+	if _, err := v.translator(opts.Locale); err != nil {
+		return Metadata{}, err
+	}
+
+	var md mapstructure.Metadata
+	cfg := &mapstructure.DecoderConfig{
+		Metadata:         &md,
+		Result:           event,
+		WeaklyTypedInput: opts.WeaklyTyped,
+		TagName:          opts.TagName,
+	}
+	if cfg.TagName == "" {
+		cfg.TagName = DefaultTagName
+	}
+	if len(opts.Hooks) > 0 {
+		cfg.DecodeHook = mapstructure.ComposeDecodeHookFunc(opts.Hooks...)
+	}
+
+	dec, err := mapstructure.NewDecoder(cfg)
 	if err != nil {
-		return err
+		return Metadata{}, err
 	}
-	return validate.Struct(event)
+
+	err = dec.Decode(data)
+	metadata := Metadata{Keys: md.Keys, Unused: md.Unused, Unset: md.Unset}
+	if err != nil {
+		return metadata, err
+	}
+
+	if opts.Strict && len(metadata.Unused) > 0 {
+		keys := slices.Clone(metadata.Unused)
+		slices.Sort(keys)
+
+		return metadata, &UnusedKeysError{Keys: keys}
+	}
+
+	return metadata, v.structWith(event, cfg.TagName, opts.Locale)
 }
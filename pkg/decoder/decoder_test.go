@@ -0,0 +1,112 @@
+package decoder
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/assert"
+)
+
+type level string
+
+type nested struct {
+	Name string `mapstructure:"name" json:"full_name"`
+}
+
+type event struct {
+	A        float64        `mapstructure:"a" json:"a" validate:"required"`
+	Nested   nested         `mapstructure:"nested" json:"nested"`
+	At       time.Time      `mapstructure:"at" json:"at"`
+	Timeout  time.Duration  `mapstructure:"timeout" json:"timeout"`
+	Total    *big.Int       `mapstructure:"total" json:"total"`
+	Ratio    big.Float      `mapstructure:"ratio" json:"ratio"`
+	Level    level          `mapstructure:"level" json:"level"`
+	Optional map[string]int `mapstructure:"optional" json:"optional"`
+}
+
+func TestDecodeWith(t *testing.T) {
+	hooks := []mapstructure.DecodeHookFunc{
+		TimeHook(time.RFC3339), DurationHook(), BigNumberHook(), EnumHook[level]("debug", "info"),
+	}
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		opts    Options
+		wantErr bool
+	}{
+		{"unknown keys ignored", map[string]interface{}{"a": 1, "b": 2}, Options{}, false},
+		{"unknown keys rejected when strict", map[string]interface{}{"a": 1, "b": 2}, Options{Strict: true}, true},
+		{"unknown nested keys rejected when strict",
+			map[string]interface{}{"a": 1, "nested": map[string]interface{}{"other": "x"}}, Options{Strict: true}, true},
+		{"known keys accepted when strict",
+			map[string]interface{}{"a": 1, "nested": map[string]interface{}{"name": "x"}}, Options{Strict: true}, false},
+		{"string number rejected", map[string]interface{}{"a": "1"}, Options{}, true},
+		{"string number converted when weakly typed", map[string]interface{}{"a": "1"}, Options{WeaklyTyped: true}, false},
+		{"validation still applies", map[string]interface{}{"a": 0}, Options{WeaklyTyped: true}, true},
+		{"time rejected without hook", map[string]interface{}{"a": 1, "at": "2024-01-02T03:04:05Z"}, Options{}, true},
+		{"hooks", map[string]interface{}{"a": 1, "at": "2024-01-02T03:04:05Z", "timeout": "1m30s",
+			"total": "123456789012345678901234567890", "ratio": 0.5, "level": "info"}, Options{Hooks: hooks}, false},
+		{"invalid enum", map[string]interface{}{"a": 1, "level": "trace"}, Options{Hooks: hooks}, true},
+		{"invalid big number", map[string]interface{}{"a": 1, "total": "1.5"}, Options{Hooks: hooks}, true},
+		{"json tag name", map[string]interface{}{"a": 1, "nested": map[string]interface{}{"full_name": "x"}},
+			Options{Strict: true, TagName: "json"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e event
+			if _, err := DecodeWith(tt.data, &e, tt.opts); (err != nil) != tt.wantErr {
+				t.Errorf("DecodeWith returns error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeWithValues(t *testing.T) {
+	var e event
+	md, err := DecodeWith(map[string]interface{}{
+		"a":       "2.5",
+		"at":      "2024-01-02T03:04:05Z",
+		"timeout": "1m30s",
+		"total":   "123456789012345678901234567890",
+		"ratio":   "0.25",
+		"level":   "debug",
+		"extra":   true,
+		"nested":  map[string]interface{}{"name": "x", "other": 1},
+	}, &e, Options{WeaklyTyped: true, Hooks: []mapstructure.DecodeHookFunc{
+		TimeHook(time.RFC3339), DurationHook(), BigNumberHook(), EnumHook[level]("debug"),
+	}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, e.A)
+	assert.Equal(t, "x", e.Nested.Name)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), e.At)
+	assert.Equal(t, 90*time.Second, e.Timeout)
+	assert.Equal(t, "123456789012345678901234567890", e.Total.String())
+	assert.Equal(t, "0.25", e.Ratio.Text('f', 2))
+	assert.Equal(t, level("debug"), e.Level)
+	assert.ElementsMatch(t, []string{"extra", "nested.other"}, md.Unused)
+	assert.Equal(t, []string{"optional"}, md.Unset)
+	assert.Contains(t, md.Keys, "nested.name")
+}
+
+func TestDecodeWithStrictError(t *testing.T) {
+	var e event
+	_, err := DecodeWith(map[string]interface{}{"a": 1, "c": 1, "b": 2}, &e, Options{Strict: true})
+
+	var unused *UnusedKeysError
+	assert.True(t, errors.As(err, &unused))
+	assert.Equal(t, []string{"b", "c"}, unused.Keys)
+	assert.Equal(t, "unknown keys: b, c", err.Error())
+}
+
+func TestEnumHook(t *testing.T) {
+	var e event
+	_, err := DecodeWith(map[string]interface{}{"a": 1, "level": "trace"}, &e,
+		Options{Hooks: []mapstructure.DecodeHookFunc{EnumHook[level]("debug")}})
+
+	assert.ErrorContains(t, err, `error decoding 'level': "trace" is not one of [debug]`)
+}
@@ -0,0 +1,134 @@
+package decoder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/de"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/it"
+	"github.com/go-playground/locales/nl"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	de_translations "github.com/go-playground/validator/v10/translations/de"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	it_translations "github.com/go-playground/validator/v10/translations/it"
+	nl_translations "github.com/go-playground/validator/v10/translations/nl"
+)
+
+// DefaultLocale of the validation messages when Options.Locale is not set
+const DefaultLocale = "en"
+
+// ErrUnsupportedLocale is returned for the locales without translations of the validation messages
+var ErrUnsupportedLocale = errors.New("unsupported locale")
+
+type registerTranslations func(v *validator.Validate, trans ut.Translator) error
+
+var translations = []struct {
+	locale   locales.Translator
+	register registerTranslations
+}{
+	{en.New(), en_translations.RegisterDefaultTranslations},
+	{de.New(), de_translations.RegisterDefaultTranslations},
+	{es.New(), es_translations.RegisterDefaultTranslations},
+	{fr.New(), fr_translations.RegisterDefaultTranslations},
+	{it.New(), it_translations.RegisterDefaultTranslations},
+	{nl.New(), nl_translations.RegisterDefaultTranslations},
+}
+
+func newUniversalTranslator() *ut.UniversalTranslator {
+	supported := make([]locales.Translator, 0, len(translations))
+	for _, t := range translations {
+		supported = append(supported, t.locale)
+	}
+
+	return ut.New(translations[0].locale, supported...)
+}
+
+// registerValidations names the fields by their tagName key in the data and translates their errors with uni
+func registerValidations(v *validator.Validate, uni *ut.UniversalTranslator, tagName string) {
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get(tagName), ",")
+		if name == "" {
+			return field.Name
+		}
+		if name == "-" {
+			return ""
+		}
+
+		return name
+	})
+
+	for _, t := range translations {
+		trans, _ := uni.GetTranslator(t.locale.Locale())
+		if err := t.register(v, trans); err != nil {
+			panic(fmt.Sprintf("registering the %s validation messages: %v", t.locale.Locale(), err))
+		}
+	}
+}
+
+// Translator returns the translator of the default validation messages in locale, DefaultLocale when empty
+func Translator(locale string) (ut.Translator, error) {
+	return defaultValidator.translator(locale)
+}
+
+// FieldError is the failed validation of a field
+type FieldError struct {
+	// Field is the path of the field in the data, e.g. "a" or "items[0].name"
+	Field string `json:"field"`
+	// Tag is the failed validation, e.g. "required" or "gte"
+	Tag string `json:"tag"`
+	// Param of the validation, e.g. "0" for "gte=0"
+	Param string `json:"param,omitempty"`
+	// Message describes the error in the locale of the decoder
+	Message string `json:"message"`
+}
+
+// ValidationErrors is returned by Decode and DecodeWith for the decoded structs failing their validation
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fe := range e {
+		messages = append(messages, fe.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// newValidationErrors translates the errors of validator.ValidationErrors, other errors are returned as is
+func newValidationErrors(err error, trans ut.Translator) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	fields := make(ValidationErrors, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Tag:     fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(trans),
+		})
+	}
+
+	return fields
+}
+
+// fieldPath removes the struct name from namespace, "Event.items[0].name" is "items[0].name"
+func fieldPath(namespace string) string {
+	_, path, found := strings.Cut(namespace, ".")
+	if !found {
+		return namespace
+	}
+
+	return path
+}
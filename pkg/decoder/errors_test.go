@@ -0,0 +1,90 @@
+package decoder
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	Name string `mapstructure:"name" validate:"required"`
+}
+
+type order struct {
+	Quantity int    `mapstructure:"quantity" validate:"gte=1"`
+	Items    []item `mapstructure:"items" validate:"dive"`
+	Note     string `validate:"max=3"`
+}
+
+func TestValidationErrors(t *testing.T) {
+	data := map[string]interface{}{
+		"quantity": 0,
+		"items":    []interface{}{map[string]interface{}{"name": "a"}, map[string]interface{}{}},
+		"Note":     "long",
+	}
+	tests := []struct {
+		name   string
+		locale string
+		want   ValidationErrors
+	}{
+		{"default locale", "", ValidationErrors{
+			{Field: "quantity", Tag: "gte", Param: "1", Message: "quantity must be 1 or greater"},
+			{Field: "items[1].name", Tag: "required", Message: "name is a required field"},
+			{Field: "Note", Tag: "max", Param: "3", Message: "Note must be a maximum of 3 characters in length"},
+		}},
+		{"fr", "fr", ValidationErrors{
+			{Field: "quantity", Tag: "gte", Param: "1", Message: "quantity doit être 1 ou plus"},
+			{Field: "items[1].name", Tag: "required", Message: "name est un champ obligatoire"},
+			{Field: "Note", Tag: "max", Param: "3", Message: "Note doit faire une taille maximum de 3 caractères"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o order
+			_, err := DecodeWith(data, &o, Options{Locale: tt.locale})
+
+			var got ValidationErrors
+			assert.True(t, errors.As(err, &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var o order
+		err := Decode(map[string]interface{}{"quantity": 1, "Note": "long"}, &o)
+
+		b, jsonErr := json.Marshal(err)
+		assert.Nil(t, jsonErr)
+		assert.JSONEq(t, `[{"field":"Note","tag":"max","param":"3",
+			"message":"Note must be a maximum of 3 characters in length"}]`, string(b))
+		assert.Equal(t, "Note must be a maximum of 3 characters in length", err.Error())
+	})
+
+	t.Run("tagName", func(t *testing.T) {
+		type jsonItem struct {
+			Name string `json:"item_name" validate:"required"`
+		}
+		type jsonOrder struct {
+			Items []jsonItem `json:"order_items" validate:"dive"`
+		}
+
+		var o jsonOrder
+		data := map[string]interface{}{"order_items": []interface{}{map[string]interface{}{}}}
+		_, err := DecodeWith(data, &o, Options{TagName: "json"})
+
+		var got ValidationErrors
+		assert.True(t, errors.As(err, &got))
+		assert.Equal(t, ValidationErrors{
+			{Field: "order_items[0].item_name", Tag: "required", Message: "item_name is a required field"},
+		}, got)
+	})
+
+	t.Run("unsupportedLocale", func(t *testing.T) {
+		var o order
+		_, err := DecodeWith(map[string]interface{}{"quantity": 1}, &o, Options{Locale: "xx"})
+		assert.ErrorIs(t, err, ErrUnsupportedLocale)
+	})
+}
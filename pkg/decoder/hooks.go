@@ -0,0 +1,87 @@
+package decoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+)
+
+// TimeHook decodes the strings formatted with layout, e.g. time.RFC3339, into time.Time fields
+func TimeHook(layout string) mapstructure.DecodeHookFunc {
+	return mapstructure.StringToTimeHookFunc(layout)
+}
+
+// DurationHook decodes the strings formatted as "1h30m" into time.Duration fields
+func DurationHook() mapstructure.DecodeHookFunc {
+	return mapstructure.StringToTimeDurationHookFunc()
+}
+
+// BigNumberHook decodes the numbers, json.Number and strings into big.Int and big.Float fields, or pointers to them.
+// The strings keep the precision of the numbers above 2^53, lost once decoded as float64.
+func BigNumberHook() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if to != bigIntType && to != bigFloatType {
+			return data, nil
+		}
+
+		var number string
+		switch v := data.(type) {
+		case string:
+			number = v
+		case json.Number:
+			number = v.String()
+		case float32, float64:
+			number = strconv.FormatFloat(reflect.ValueOf(v).Float(), 'f', -1, 64)
+		case int, int8, int16, int32, int64:
+			number = strconv.FormatInt(reflect.ValueOf(v).Int(), 10)
+		case uint, uint8, uint16, uint32, uint64:
+			number = strconv.FormatUint(reflect.ValueOf(v).Uint(), 10)
+		default:
+			return data, nil
+		}
+
+		if to == bigIntType {
+			n, ok := new(big.Int).SetString(number, 10)
+			if !ok {
+				return nil, fmt.Errorf("%q is not an integer", number)
+			}
+
+			return n, nil
+		}
+
+		n, ok := new(big.Float).SetString(number)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a number", number)
+		}
+
+		return n, nil
+	}
+}
+
+// EnumHook rejects the strings decoded into T fields that are not one of values
+func EnumHook[T ~string](values ...T) mapstructure.DecodeHookFunc {
+	enumType := reflect.TypeOf(T(""))
+
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if to != enumType || from.Kind() != reflect.String {
+			return data, nil
+		}
+
+		value := T(reflect.ValueOf(data).String())
+		if !slices.Contains(values, value) {
+			return nil, fmt.Errorf("%q is not one of %v", value, values)
+		}
+
+		return data, nil
+	}
+}
@@ -0,0 +1,214 @@
+package decoder
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// Tags of the validations registered by NewValidator, in addition to the go-playground/validator ones
+const (
+	// TagFinite rejects the NaN and infinite floats
+	TagFinite = "finite"
+)
+
+var defaultValidator = NewValidator()
+
+// Validator validates the decoded events. Each Validator has its own rules, the ones registered
+// by a service do not apply to the others. It must not be modified once used.
+type Validator struct {
+	mu    sync.Mutex
+	rules []func(val *validation) error
+	byTag map[string]*validation
+}
+
+// validation validates the structs naming their fields by the key of a struct tag
+type validation struct {
+	validate  *validator.Validate
+	universal *ut.UniversalTranslator
+}
+
+// NewValidator returns a Validator with the go-playground/validator rules and TagFinite,
+// naming the fields by their key in the struct tag of the decoder, DefaultTagName for Struct
+func NewValidator() *Validator {
+	v := &Validator{byTag: map[string]*validation{DefaultTagName: newValidation(DefaultTagName)}}
+	if err := v.RegisterValidation(TagFinite, isFinite, "{0} must be a finite number"); err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+func newValidation(tagName string) *validation {
+	val := &validation{validate: validator.New(), universal: newUniversalTranslator()}
+	registerValidations(val.validate, val.universal, tagName)
+
+	return val
+}
+
+// RegisterValidation adds the field validation tag, failing with message in every locale.
+// message can reference the field with {0} and the param of the tag with {1}, e.g. "{0} must be lower than {1}".
+// An empty message keeps the untranslated validator message.
+func (v *Validator) RegisterValidation(tag string, fn validator.Func, message string) error {
+	return v.register(func(val *validation) error {
+		if err := val.validate.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+
+		return val.registerMessage(tag, message)
+	})
+}
+
+// RegisterAlias adds the tag alias standing for tags, e.g. "percent" for "gte=0,lte=100",
+// failing with message in every locale
+func (v *Validator) RegisterAlias(alias, tags, message string) error {
+	return v.register(func(val *validation) error {
+		val.validate.RegisterAlias(alias, tags)
+
+		return val.registerMessage(alias, message)
+	})
+}
+
+// RegisterStructValidation adds fn, validating the fields of types together, e.g. that a field is lower than another.
+// fn reports the invalid fields with validator.StructLevel.ReportError and a tag, translated once
+// registered with RegisterTranslation.
+func (v *Validator) RegisterStructValidation(fn validator.StructLevelFunc, types ...interface{}) {
+	_ = v.register(func(val *validation) error {
+		val.validate.RegisterStructValidation(fn, types...)
+
+		return nil
+	})
+}
+
+// RegisterTranslation sets the message of tag in locale, overriding the one of RegisterValidation
+func (v *Validator) RegisterTranslation(locale, tag, message string) error {
+	return v.register(func(val *validation) error {
+		return val.registerTranslation(locale, tag, message)
+	})
+}
+
+// Struct validates the fields of s, returning ValidationErrors with their messages in locale
+// and the fields named by their DefaultTagName key
+func (v *Validator) Struct(s interface{}, locale string) error {
+	return v.structWith(s, DefaultTagName, locale)
+}
+
+// structWith validates the fields of s like Struct, naming the fields by their tagName key
+func (v *Validator) structWith(s interface{}, tagName, locale string) error {
+	val, err := v.validation(tagName)
+	if err != nil {
+		return err
+	}
+	trans, err := val.translator(locale)
+	if err != nil {
+		return err
+	}
+
+	return newValidationErrors(val.validate.Struct(s), trans)
+}
+
+func (v *Validator) translator(locale string) (ut.Translator, error) {
+	val, err := v.validation(DefaultTagName)
+	if err != nil {
+		return nil, err
+	}
+
+	return val.translator(locale)
+}
+
+// register applies rule to the validations of every tag name, and to the ones created later
+func (v *Validator) register(rule func(val *validation) error) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, val := range v.byTag {
+		if err := rule(val); err != nil {
+			return err
+		}
+	}
+	v.rules = append(v.rules, rule)
+
+	return nil
+}
+
+// validation returns the validation of tagName, created with the registered rules on first use
+func (v *Validator) validation(tagName string) (*validation, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if val, found := v.byTag[tagName]; found {
+		return val, nil
+	}
+
+	val := newValidation(tagName)
+	for _, rule := range v.rules {
+		if err := rule(val); err != nil {
+			return nil, err
+		}
+	}
+	v.byTag[tagName] = val
+
+	return val, nil
+}
+
+func (val *validation) translator(locale string) (ut.Translator, error) {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+
+	trans, found := val.universal.GetTranslator(locale)
+	if !found {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedLocale, locale)
+	}
+
+	return trans, nil
+}
+
+func (val *validation) registerTranslation(locale, tag, message string) error {
+	trans, err := val.translator(locale)
+	if err != nil {
+		return err
+	}
+
+	return val.validate.RegisterTranslation(tag, trans, func(trans ut.Translator) error {
+		return trans.Add(tag, message, true)
+	}, translateFieldError)
+}
+
+func (val *validation) registerMessage(tag, message string) error {
+	if message == "" {
+		return nil
+	}
+
+	for _, t := range translations {
+		if err := val.registerTranslation(t.locale.Locale(), tag, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func translateFieldError(trans ut.Translator, fe validator.FieldError) string {
+	message, err := trans.T(fe.Tag(), fe.Field(), fe.Param())
+	if err != nil {
+		return fe.Error()
+	}
+
+	return message
+}
+
+func isFinite(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	switch field.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := field.Float()
+		return !math.IsNaN(f) && !math.IsInf(f, 0)
+	default:
+		return true
+	}
+}
@@ -0,0 +1,111 @@
+package decoder
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+type division struct {
+	A float64 `mapstructure:"a" validate:"finite"`
+	B float64 `mapstructure:"b" validate:"finite,nonzero"`
+}
+
+type interval struct {
+	Min     int `mapstructure:"min"`
+	Max     int `mapstructure:"max"`
+	Percent int `mapstructure:"percent" validate:"percent"`
+}
+
+func newTestValidator(t *testing.T) *Validator {
+	v := NewValidator()
+	assert.Nil(t, v.RegisterValidation("nonzero", func(fl validator.FieldLevel) bool {
+		return !fl.Field().IsZero()
+	}, "{0} must not be zero"))
+	assert.Nil(t, v.RegisterTranslation("fr", "nonzero", "{0} ne doit pas être zéro"))
+	assert.Nil(t, v.RegisterAlias("percent", "gte=0,lte=100", "{0} must be a percentage"))
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		i := sl.Current().Interface().(interval)
+		if i.Min > i.Max {
+			sl.ReportError(i.Min, "min", "Min", "ltefield", "max")
+		}
+	}, interval{})
+	assert.Nil(t, v.RegisterTranslation("en", "ltefield", "{0} must be lower than or equal to {1}"))
+
+	return v
+}
+
+func TestValidator(t *testing.T) {
+	v := newTestValidator(t)
+	tests := []struct {
+		name   string
+		data   map[string]interface{}
+		event  interface{}
+		locale string
+		want   ValidationErrors
+	}{
+		{"valid division", map[string]interface{}{"a": 1, "b": 2}, &division{}, "", nil},
+		{"custom validation", map[string]interface{}{"a": 1, "b": 0}, &division{}, "", ValidationErrors{
+			{Field: "b", Tag: "nonzero", Message: "b must not be zero"},
+		}},
+		{"custom validation translated", map[string]interface{}{"a": 1, "b": 0}, &division{}, "fr", ValidationErrors{
+			{Field: "b", Tag: "nonzero", Message: "b ne doit pas être zéro"},
+		}},
+		{"custom validation in other locales", map[string]interface{}{"a": 1, "b": 0}, &division{}, "de", ValidationErrors{
+			{Field: "b", Tag: "nonzero", Message: "b must not be zero"},
+		}},
+		{"finite", map[string]interface{}{"a": "NaN", "b": "+Inf"}, &division{}, "", ValidationErrors{
+			{Field: "a", Tag: "finite", Message: "a must be a finite number"},
+			{Field: "b", Tag: "finite", Message: "b must be a finite number"},
+		}},
+		{"valid interval", map[string]interface{}{"min": 1, "max": 2, "percent": 50}, &interval{}, "", nil},
+		{"alias", map[string]interface{}{"min": 1, "max": 2, "percent": 150}, &interval{}, "", ValidationErrors{
+			{Field: "percent", Tag: "percent", Param: "100", Message: "percent must be a percentage"},
+		}},
+		{"struct validation", map[string]interface{}{"min": 3, "max": 2, "percent": 50}, &interval{}, "", ValidationErrors{
+			{Field: "min", Tag: "ltefield", Param: "max", Message: "min must be lower than or equal to max"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeWith(tt.data, tt.event, Options{WeaklyTyped: true, Locale: tt.locale, Validator: v})
+			if tt.want == nil {
+				assert.Nil(t, err)
+				return
+			}
+
+			var got ValidationErrors
+			assert.True(t, errors.As(err, &got), err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("struct", func(t *testing.T) {
+		err := v.Struct(&division{A: 1}, "")
+		assert.Equal(t, ValidationErrors{{Field: "b", Tag: "nonzero", Message: "b must not be zero"}}, err)
+	})
+
+	t.Run("tagName", func(t *testing.T) {
+		var d struct {
+			B float64 `json:"divisor" validate:"nonzero"`
+		}
+		_, err := DecodeWith(map[string]interface{}{"divisor": 0}, &d, Options{TagName: "json", Locale: "fr", Validator: v})
+		assert.Equal(t, ValidationErrors{{Field: "divisor", Tag: "nonzero", Message: "divisor ne doit pas être zéro"}}, err)
+	})
+
+	t.Run("instancesDoNotShareRules", func(t *testing.T) {
+		assert.Panics(t, func() {
+			_ = Decode(map[string]interface{}{"a": 1, "b": 1}, &division{})
+		})
+		err := Decode(map[string]interface{}{"min": 3, "max": 2}, &struct {
+			Min int `mapstructure:"min" validate:"ltefield=Max"`
+			Max int `mapstructure:"max"`
+		}{})
+		assert.Equal(t, ValidationErrors{
+			{Field: "min", Tag: "ltefield", Param: "Max", Message: "min must be less than or equal to Max"},
+		}, err)
+	})
+}
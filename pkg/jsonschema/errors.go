@@ -0,0 +1,38 @@
+package jsonschema
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedType is returned for the Go types without JSON representation, e.g. channels or functions
+	ErrUnsupportedType = errors.New("unsupported type")
+	// ErrRecursiveType is returned for the types containing themselves
+	ErrRecursiveType = errors.New("recursive type")
+	// ErrDuplicateSchema is returned when a name is registered twice
+	ErrDuplicateSchema = errors.New("duplicate schema")
+	// ErrUnknownSchema is returned for the names not registered
+	ErrUnknownSchema = errors.New("unknown schema")
+)
+
+// ValidationError is a value not matching a keyword of its schema
+type ValidationError struct {
+	// Path of the value, e.g. "a" or "items[0].name", empty for the root value
+	Path string `json:"path"`
+	// Keyword not matched, e.g. "required" or "minimum"
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is returned by Schema.Validate for the values not matching the schema
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, ve := range e {
+		messages = append(messages, ve.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,310 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	schemerType       = reflect.TypeOf((*Schemer)(nil)).Elem()
+)
+
+// Schemer is implemented by the types giving their own schema, e.g. the ones read and written
+// by their own JSON methods
+type Schemer interface {
+	// JSONSchema returns the schema of the values of the type, called on its zero value
+	JSONSchema() *Schema
+}
+
+// formats of the validate tags
+var formats = map[string]string{
+	"email":    "email",
+	"url":      "uri",
+	"uri":      "uri",
+	"uuid":     "uuid",
+	"uuid4":    "uuid",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"hostname": "hostname",
+}
+
+// Generate returns the schema of the values of v, a struct or a pointer to a struct.
+//
+// The properties are named by the json tag of the fields, else their mapstructure tag, else their name.
+// The validate tags set the required properties and the constraints of the values: min, max, len,
+// gt, gte, lt, lte, oneof and the formats email, url, uri, uuid, ipv4, ipv6 and hostname.
+// The tags after dive apply to the items of the arrays.
+// The types implementing Schemer give their own schema, the other json.Marshaler accept any value.
+func Generate(v interface{}) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %v is not a struct", ErrUnsupportedType, t)
+	}
+
+	s, err := generate(t, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	s.Schema = Draft
+
+	return s, nil
+}
+
+func generate(t reflect.Type, seen map[reflect.Type]bool) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: TypeString, Format: "date-time"}, nil
+	}
+	if reflect.PointerTo(t).Implements(schemerType) {
+		return reflect.New(t).Interface().(Schemer).JSONSchema(), nil
+	}
+	// the other values marshalled by themselves can have any representation, they are not validated
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return &Schema{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: TypeInteger}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: TypeNumber}, nil
+	case reflect.String:
+		return &Schema{Type: TypeString}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		// encoding/json writes the []byte as base64 strings
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: TypeString}, nil
+		}
+		items, err := generate(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Schema{Type: TypeArray, Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%w: %v keys are not strings", ErrUnsupportedType, t)
+		}
+		values, err := generate(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Schema{Type: TypeObject, AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if seen[t] {
+			return nil, fmt.Errorf("%w: %v", ErrRecursiveType, t)
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		s := &Schema{Type: TypeObject, Properties: map[string]*Schema{}}
+		if err := addFields(s, t, seen); err != nil {
+			return nil, err
+		}
+
+		return s, nil
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, t)
+	}
+}
+
+// addFields adds the exported fields of t to the properties of s, and the ones of its embedded structs
+func addFields(s *Schema, t reflect.Type, seen map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, embedded := fieldName(field)
+		if name == "-" || (!field.IsExported() && !embedded) {
+			continue
+		}
+
+		if embedded {
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if err := addFields(s, ft, seen); err != nil {
+				return err
+			}
+			continue
+		}
+
+		property, err := generate(field.Type, seen)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+		required, err := applyValidateTag(property, field.Type, field.Tag.Get("validate"))
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+
+		s.Properties[name] = property
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	return nil
+}
+
+// fieldName returns the property name of field, and whether its fields are the ones of the parent struct
+func fieldName(field reflect.StructField) (string, bool) {
+	for _, tag := range []string{"json", "mapstructure"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" {
+			return name, false
+		}
+	}
+
+	ft := field.Type
+	for ft.Kind() == reflect.Pointer {
+		ft = ft.Elem()
+	}
+
+	return field.Name, field.Anonymous && ft.Kind() == reflect.Struct
+}
+
+// applyValidateTag sets the constraints of the validate tag to s, the schema of t, and returns whether it is required
+func applyValidateTag(s *Schema, t reflect.Type, tag string) (bool, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	required := false
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			if s.Items == nil {
+				return required, nil
+			}
+			_, err := applyValidateTag(s.Items, t.Elem(), strings.Join(rules[i+1:], ","))
+
+			return required, err
+		}
+
+		var err error
+		switch name {
+		case "required":
+			required = true
+		case "min", "gte", "gt":
+			err = setMinimum(s, param, name == "gt")
+		case "max", "lte", "lt":
+			err = setMaximum(s, param, name == "lt")
+		case "len":
+			if err = setMinimum(s, param, false); err == nil {
+				err = setMaximum(s, param, false)
+			}
+		case "oneof":
+			err = setEnum(s, t, param)
+		default:
+			if format, ok := formats[name]; ok {
+				s.Format = format
+			}
+		}
+		if err != nil {
+			return required, fmt.Errorf("validate tag %q: %w", rule, err)
+		}
+	}
+
+	return required, nil
+}
+
+// setMinimum sets the minimum of the numbers, or of the lengths of the strings and arrays
+func setMinimum(s *Schema, param string, exclusive bool) error {
+	switch s.Type {
+	case TypeNumber, TypeInteger:
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return err
+		}
+		if exclusive {
+			s.ExclusiveMinimum = &n
+		} else {
+			s.Minimum = &n
+		}
+	case TypeString, TypeArray:
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return err
+		}
+		if exclusive {
+			n++
+		}
+		if s.Type == TypeString {
+			s.MinLength = &n
+		} else {
+			s.MinItems = &n
+		}
+	}
+
+	return nil
+}
+
+// setMaximum sets the maximum of the numbers, or of the lengths of the strings and arrays
+func setMaximum(s *Schema, param string, exclusive bool) error {
+	switch s.Type {
+	case TypeNumber, TypeInteger:
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return err
+		}
+		if exclusive {
+			s.ExclusiveMaximum = &n
+		} else {
+			s.Maximum = &n
+		}
+	case TypeString, TypeArray:
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return err
+		}
+		if exclusive {
+			n--
+		}
+		if s.Type == TypeString {
+			s.MaxLength = &n
+		} else {
+			s.MaxItems = &n
+		}
+	}
+
+	return nil
+}
+
+// setEnum sets the space separated values of oneof to s
+func setEnum(s *Schema, t reflect.Type, param string) error {
+	for _, value := range strings.Fields(param) {
+		switch s.Type {
+		case TypeNumber, TypeInteger:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return err
+			}
+			s.Enum = append(s.Enum, n)
+		case TypeString:
+			s.Enum = append(s.Enum, strings.Trim(value, "'"))
+		default:
+			return fmt.Errorf("%w: oneof of %v", ErrUnsupportedType, t)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Base struct {
+	ID string `json:"id" validate:"required,uuid"`
+}
+
+type line struct {
+	Name     string  `json:"name" validate:"required,max=20"`
+	Quantity int     `json:"quantity" validate:"gt=0,lte=100"`
+	Price    float64 `mapstructure:"price" validate:"gte=0"`
+}
+
+type order struct {
+	Base
+	Status   string            `json:"status" validate:"oneof=new 'paid' shipped"`
+	Priority int               `json:"priority,omitempty" validate:"oneof=1 2 3"`
+	Lines    []line            `json:"lines" validate:"required,min=1,dive"`
+	Tags     []string          `json:"tags" validate:"max=3,dive,min=2"`
+	Labels   map[string]string `json:"labels"`
+	Email    *string           `json:"email" validate:"omitempty,email"`
+	At       time.Time         `json:"at"`
+	Total    *big.Int          `json:"total"`
+	Amount   amount            `json:"amount"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+// amount is read from the JSON strings or numbers
+type amount struct {
+	value string
+}
+
+func (a amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.value)
+}
+
+func (amount) JSONSchema() *Schema {
+	return &Schema{AnyOf: []*Schema{{Type: TypeString, MinLength: &minAmountLength}, {Type: TypeNumber}}}
+}
+
+var minAmountLength = 1
+
+type recursive struct {
+	Children []recursive `json:"children"`
+}
+
+func TestGenerate(t *testing.T) {
+	s, err := Generate(&order{})
+	assert.Nil(t, err)
+
+	got, err := json.Marshal(s)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"status": {"type": "string", "enum": ["new", "paid", "shipped"]},
+			"priority": {"type": "integer", "enum": [1, 2, 3]},
+			"lines": {"type": "array", "minItems": 1, "items": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string", "maxLength": 20},
+					"quantity": {"type": "integer", "exclusiveMinimum": 0, "maximum": 100},
+					"price": {"type": "number", "minimum": 0}
+				},
+				"required": ["name"]
+			}},
+			"tags": {"type": "array", "maxItems": 3, "items": {"type": "string", "minLength": 2}},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"email": {"type": "string", "format": "email"},
+			"at": {"type": "string", "format": "date-time"},
+			"total": {},
+			"amount": {"anyOf": [{"type": "string", "minLength": 1}, {"type": "number"}]}
+		},
+		"required": ["id", "lines"]
+	}`, string(got))
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		wantErr error
+	}{
+		{"not a struct", 1, ErrUnsupportedType},
+		{"nil", nil, ErrUnsupportedType},
+		{"unsupported field", struct{ C chan int }{}, ErrUnsupportedType},
+		{"map keys", struct{ M map[int]string }{}, ErrUnsupportedType},
+		{"recursive", recursive{}, ErrRecursiveType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Generate(tt.v)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry("https://schemas.example.com/")
+	assert.Nil(t, r.Register("shop.Order", order{}))
+	assert.Nil(t, r.Register("shop.Line", line{}))
+	assert.ErrorIs(t, r.Register("shop.Line", line{}), ErrDuplicateSchema)
+	assert.ErrorIs(t, r.Register("shop.Invalid", 1), ErrUnsupportedType)
+
+	assert.Equal(t, []string{"shop.Line", "shop.Order"}, r.Names())
+	s, err := r.Get("shop.Line")
+	assert.Nil(t, err)
+	assert.Equal(t, "shop.Line", s.Title)
+	assert.Equal(t, "https://schemas.example.com/shop.Line", s.ID)
+	_, err = r.Get("shop.Invalid")
+	assert.ErrorIs(t, err, ErrUnknownSchema)
+
+	paths, err := r.WriteFiles(t.TempDir())
+	assert.Nil(t, err)
+	assert.Len(t, paths, 2)
+}
@@ -0,0 +1,109 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Registry holds the schemas of the registered types, by name
+type Registry struct {
+	mu      sync.RWMutex
+	baseID  string
+	schemas map[string]*Schema
+}
+
+// NewRegistry returns an empty Registry. The $id of the schemas is baseID followed by their name,
+// they have no $id when baseID is empty.
+func NewRegistry(baseID string) *Registry {
+	return &Registry{baseID: baseID, schemas: map[string]*Schema{}}
+}
+
+// Register generates the schema of v under name, e.g. "adder.AddEvent"
+func (r *Registry) Register(name string, v interface{}) error {
+	s, err := Generate(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	s.Title = name
+	if r.baseID != "" {
+		s.ID = r.baseID + name
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.schemas[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateSchema, name)
+	}
+	r.schemas[name] = s
+
+	return nil
+}
+
+// Get returns the schema registered under name
+func (r *Registry) Get(name string) (*Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSchema, name)
+	}
+
+	return s, nil
+}
+
+// Names returns the registered names, sorted
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.schemas))
+	for name := range r.schemas {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// WriteFiles writes the schemas to dir, one <name>.schema.json file per schema, and returns their paths
+func (r *Registry) WriteFiles(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	names := r.Names()
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		s, err := r.Get(name)
+		if err != nil {
+			return paths, err
+		}
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return paths, err
+		}
+
+		path := filepath.Join(dir, strings.ReplaceAll(name, "/", "_")+".schema.json")
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// MarshalJSON writes the schemas as a JSON object, by name
+func (r *Registry) MarshalJSON() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return json.Marshal(r.schemas)
+}
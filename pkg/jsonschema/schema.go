@@ -0,0 +1,47 @@
+package jsonschema
+
+// Draft is the JSON Schema version of the generated schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Types of the values
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+)
+
+// Schema is the subset of JSON Schema generated from the Go types. The unset keywords are omitted.
+type Schema struct {
+	Schema string `json:"$schema,omitempty"`
+	ID     string `json:"$id,omitempty"`
+	Title  string `json:"title,omitempty"`
+
+	Type   string        `json:"type,omitempty"`
+	Format string        `json:"format,omitempty"`
+	Enum   []interface{} `json:"enum,omitempty"`
+	// AnyOf are the alternative schemas of the value, valid against at least one of them
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+
+	// objects
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+
+	// arrays
+	Items    *Schema `json:"items,omitempty"`
+	MinItems *int    `json:"minItems,omitempty"`
+	MaxItems *int    `json:"maxItems,omitempty"`
+
+	// strings
+	MinLength *int `json:"minLength,omitempty"`
+	MaxLength *int `json:"maxLength,omitempty"`
+
+	// numbers
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+}
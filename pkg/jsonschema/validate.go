@@ -0,0 +1,204 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateJSON validates the JSON value data against s
+func (s *Schema) ValidateJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	return s.Validate(value)
+}
+
+// Validate validates value, decoded from JSON by encoding/json, against the keywords of s generated by Generate,
+// but format.
+// The values not matching are returned as ValidationErrors.
+func (s *Schema) Validate(value interface{}) error {
+	var errs ValidationErrors
+	s.validate("", value, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (s *Schema) validate(path string, value interface{}, errs *ValidationErrors) {
+	report := func(keyword, format string, args ...interface{}) {
+		message := describe(path) + fmt.Sprintf(format, args...)
+		*errs = append(*errs, ValidationError{Path: path, Keyword: keyword, Message: message})
+	}
+
+	if len(s.AnyOf) > 0 && !s.validateAnyOf(path, value, errs) {
+		return
+	}
+	if !s.matchesType(value) {
+		report("type", " must be of type %s", s.Type)
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e interface{}) bool { return equal(e, value) }) {
+		report("enum", " must be one of %v", s.Enum)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, ValidationError{
+					Path: join(path, name), Keyword: "required", Message: describe(join(path, name)) + " is required",
+				})
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			property := v[name]
+			if schema, ok := s.Properties[name]; ok {
+				schema.validate(join(path, name), property, errs)
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.validate(join(path, name), property, errs)
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			report("minItems", " must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			report("maxItems", " must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(path+"["+strconv.Itoa(i)+"]", item, errs)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			report("minLength", " must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			report("maxLength", " must be at most %d characters long", *s.MaxLength)
+		}
+	default:
+		n, ok := number(value)
+		if !ok {
+			return
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			report("minimum", " must be %v or greater", *s.Minimum)
+		}
+		if s.ExclusiveMinimum != nil && n <= *s.ExclusiveMinimum {
+			report("exclusiveMinimum", " must be greater than %v", *s.ExclusiveMinimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			report("maximum", " must be %v or less", *s.Maximum)
+		}
+		if s.ExclusiveMaximum != nil && n >= *s.ExclusiveMaximum {
+			report("exclusiveMaximum", " must be less than %v", *s.ExclusiveMaximum)
+		}
+	}
+}
+
+// validateAnyOf reports whether value is valid against one of s.AnyOf. Otherwise the errors of the first
+// alternative of the type of value are added to errs, or a type error when none has the type of value.
+func (s *Schema) validateAnyOf(path string, value interface{}, errs *ValidationErrors) bool {
+	var types []string
+	var typed ValidationErrors
+	matchedType := false
+	for _, alternative := range s.AnyOf {
+		var alternativeErrs ValidationErrors
+		alternative.validate(path, value, &alternativeErrs)
+		if len(alternativeErrs) == 0 {
+			return true
+		}
+
+		types = append(types, alternative.Type)
+		if !matchedType && alternative.matchesType(value) {
+			typed, matchedType = alternativeErrs, true
+		}
+	}
+
+	if matchedType {
+		*errs = append(*errs, typed...)
+	} else {
+		*errs = append(*errs, ValidationError{
+			Path: path, Keyword: "type", Message: describe(path) + " must be of type " + strings.Join(types, " or "),
+		})
+	}
+
+	return false
+}
+
+func (s *Schema) matchesType(value interface{}) bool {
+	switch s.Type {
+	case "":
+		return true
+	case TypeObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case TypeArray:
+		_, ok := value.([]interface{})
+		return ok
+	case TypeString:
+		_, ok := value.(string)
+		return ok
+	case TypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case TypeNumber:
+		_, ok := number(value)
+		return ok
+	case TypeInteger:
+		n, ok := number(value)
+		return ok && n == math.Trunc(n)
+	default:
+		return false
+	}
+}
+
+// number returns the value of the JSON numbers, decoded as float64 or json.Number
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func equal(a, b interface{}) bool {
+	if n, ok := number(b); ok {
+		m, ok := number(a)
+		return ok && m == n
+	}
+
+	return a == b
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+func describe(path string) string {
+	if path == "" {
+		return "value"
+	}
+
+	return path
+}
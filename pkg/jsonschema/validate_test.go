@@ -0,0 +1,55 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	s, err := Generate(order{})
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{"valid", `{"id":"9b2e7c4e-1a6f-4c1b-9d3e-2f1b8c7a6d5e","lines":[{"name":"a","quantity":1}],"status":"paid",
+			"priority":2,"tags":["ab"],"labels":{"k":"v"},"total":12345678901234567890,"amount":"1.5","unknown":true}`, nil},
+		{"missing required", `{"lines":[{"quantity":1}]}`, ValidationErrors{
+			{Path: "id", Keyword: "required", Message: "id is required"},
+			{Path: "lines[0].name", Keyword: "required", Message: "lines[0].name is required"},
+		}},
+		{"constraints", `{"id":"x","status":"lost","priority":4,"lines":[{"name":"a","quantity":0,"price":-1}],
+			"tags":["a","bb","cc","dd"]}`, ValidationErrors{
+			{Path: "lines[0].price", Keyword: "minimum", Message: "lines[0].price must be 0 or greater"},
+			{Path: "lines[0].quantity", Keyword: "exclusiveMinimum", Message: "lines[0].quantity must be greater than 0"},
+			{Path: "priority", Keyword: "enum", Message: "priority must be one of [1 2 3]"},
+			{Path: "status", Keyword: "enum", Message: "status must be one of [new paid shipped]"},
+			{Path: "tags", Keyword: "maxItems", Message: "tags must have at most 3 items"},
+			{Path: "tags[0]", Keyword: "minLength", Message: "tags[0] must be at least 2 characters long"},
+		}},
+		{"types", `{"id":1,"lines":[{"name":"a","quantity":1.5}],"labels":{"k":2},"amount":true}`, ValidationErrors{
+			{Path: "amount", Keyword: "type", Message: "amount must be of type string or number"},
+			{Path: "id", Keyword: "type", Message: "id must be of type string"},
+			{Path: "labels.k", Keyword: "type", Message: "labels.k must be of type string"},
+			{Path: "lines[0].quantity", Keyword: "type", Message: "lines[0].quantity must be of type integer"},
+		}},
+		{"alternative constraints", `{"id":"x","lines":[{"name":"a","quantity":1}],"amount":""}`, ValidationErrors{
+			{Path: "amount", Keyword: "minLength", Message: "amount must be at least 1 characters long"},
+		}},
+		{"other alternative", `{"id":"x","lines":[{"name":"a","quantity":1}],"amount":1.5}`, nil},
+		{"root type", `[]`, ValidationErrors{{Keyword: "type", Message: "value must be of type object"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.ValidateJSON([]byte(tt.data)))
+		})
+	}
+
+	t.Run("invalidJSON", func(t *testing.T) {
+		assert.Error(t, s.ValidateJSON([]byte(`{`)))
+	})
+}
@@ -0,0 +1,30 @@
+package logger
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx.
+// A logger for the "default" section is returned when ctx has none.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+
+	return New("default", "")
+}
+
+// WithAction returns a copy of the logger, with its meta, for another action
+func (l *Logger) WithAction(action string) *Logger {
+	c := New(l.section, action)
+	for k, v := range l.meta {
+		c.meta[k] = v
+	}
+
+	return c
+}
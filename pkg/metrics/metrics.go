@@ -0,0 +1,48 @@
+// Package metrics keeps process-wide counters.
+//
+// The counters are published through expvar, and served as JSON under Path by Serve.
+package metrics
+
+import (
+	"expvar"
+	"sync"
+)
+
+var mu sync.Mutex
+
+// Counter returns the counter registered as name, creating it on first use
+func Counter(name string) *expvar.Int {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if v, ok := expvar.Get(name).(*expvar.Int); ok {
+		return v
+	}
+
+	return expvar.NewInt(name)
+}
+
+// CounterVec returns the labeled counters registered as name, creating them on first use.
+// Increment a label with Add(label, 1).
+func CounterVec(name string) *expvar.Map {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if v, ok := expvar.Get(name).(*expvar.Map); ok {
+		return v
+	}
+
+	return expvar.NewMap(name)
+}
+
+// Gauge returns the gauge registered as name, creating it on first use
+func Gauge(name string) *expvar.Float {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if v, ok := expvar.Get(name).(*expvar.Float); ok {
+		return v
+	}
+
+	return expvar.NewFloat(name)
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ventive/go-mono-template/pkg/logger"
+)
+
+const (
+	// DefaultAddr the counters are served on when Config.Addr is not set
+	DefaultAddr = ":9090"
+	// Path of the counters, served as a JSON object with the other expvar variables
+	Path = "/debug/vars"
+
+	readHeaderTimeout = 5 * time.Second
+)
+
+// Config godoc
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Addr the counters are served on, e.g. ":9090". DefaultAddr when empty.
+	Addr string `mapstructure:"addr"`
+}
+
+// Handler serves the counters as JSON under Path
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(Path, expvar.Handler())
+
+	return mux
+}
+
+// Serve listens on cfg.Addr and serves the counters with Handler, in the background.
+// The returned function stops the server and must be called before exiting.
+// When the metrics are disabled nothing is served.
+func Serve(cfg Config) (func(context.Context) error, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	addr := cfg.Addr
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &http.Server{Handler: Handler(), ReadHeaderTimeout: readHeaderTimeout}
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			logger.New("metrics", "metrics.Serve").Error("Metrics server stopped", err)
+		}
+	}()
+
+	return server.Shutdown, nil
+}
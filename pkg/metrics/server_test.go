@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	Gauge("metrics_unit_tests").Set(1.5)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var vars map[string]json.RawMessage
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &vars))
+	assert.JSONEq(t, `1.5`, string(vars["metrics_unit_tests"]))
+}
+
+func TestServe(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		shutdown, err := Serve(Config{Addr: "invalid"})
+		assert.Nil(t, err)
+		assert.Nil(t, shutdown(context.Background()))
+	})
+
+	t.Run("invalidAddr", func(t *testing.T) {
+		_, err := Serve(Config{Enabled: true, Addr: "invalid"})
+		assert.Error(t, err)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		shutdown, err := Serve(Config{Enabled: true, Addr: "127.0.0.1:0"})
+		assert.Nil(t, err)
+		assert.Nil(t, shutdown(context.Background()))
+	})
+}
@@ -0,0 +1,206 @@
+// Package breaker implements circuit breakers protecting the NATS publishes and requests
+// made to dependencies that keep failing.
+package breaker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/metrics"
+)
+
+// State of a circuit breaker
+type State int
+
+// States of a circuit breaker
+const (
+	// StateClosed lets every call through
+	StateClosed State = iota
+	// StateOpen fails every call fast until the cool-down is over
+	StateOpen
+	// StateHalfOpen lets a limited number of probe calls through
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// Config godoc
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Subjects protected by a breaker each. Wildcards are supported,
+	// all the subjects matching a pattern share its breaker.
+	Subjects []string `mapstructure:"subjects"`
+	// ConsecutiveFailures opening the breaker. Zero disables this threshold.
+	ConsecutiveFailures int `mapstructure:"consecutive_failures"`
+	// FailureRate, between 0 and 1, opening the breaker once MinRequests calls were made in Window.
+	// Zero disables this threshold.
+	FailureRate float64 `mapstructure:"failure_rate"`
+	MinRequests int     `mapstructure:"min_requests"`
+	// Window after which the failure rate counts are reset
+	Window time.Duration `mapstructure:"window"`
+	// CoolDown is how long the breaker stays open before letting probe calls through
+	CoolDown time.Duration `mapstructure:"cool_down"`
+	// HalfOpenRequests is the number of successful probe calls needed to close the breaker
+	HalfOpenRequests int `mapstructure:"half_open_requests"`
+}
+
+// Breaker is a circuit breaker with closed, open and half-open states
+type Breaker struct {
+	name string
+	cfg  Config
+	now  func() time.Time
+
+	mu                  sync.Mutex
+	state               State
+	openedAt            time.Time
+	windowStart         time.Time
+	requests            int
+	failures            int
+	consecutiveFailures int
+	probes              int
+	probeSuccesses      int
+	// generation changes with the state, the calls allowed in a previous state are not counted
+	generation uint64
+}
+
+// New creates a closed breaker, name identifies it in logs and metrics
+func New(name string, cfg Config) *Breaker {
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = 1
+	}
+
+	b := &Breaker{name: name, cfg: cfg, now: time.Now}
+	b.windowStart = b.now()
+
+	return b
+}
+
+// State returns the current state of the breaker
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+
+	return b.state
+}
+
+// Allow returns an *OpenError when the call must fail fast.
+// Otherwise the outcome of the call must be reported by calling done. The outcomes reported
+// after the breaker changed state are ignored, e.g. a call allowed while closed is no half-open probe.
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+
+	switch b.state {
+	case StateOpen:
+		metrics.CounterVec("nats_circuit_breaker_rejected_total").Add(b.name, 1)
+		return nil, &OpenError{Name: b.name, RetryAfter: b.openedAt.Add(b.cfg.CoolDown).Sub(b.now())}
+	case StateHalfOpen:
+		if b.probes >= b.cfg.HalfOpenRequests {
+			metrics.CounterVec("nats_circuit_breaker_rejected_total").Add(b.name, 1)
+			return nil, &OpenError{Name: b.name}
+		}
+		b.probes++
+	}
+
+	generation := b.generation
+
+	return func(err error) { b.done(generation, err) }, nil
+}
+
+func (b *Breaker) done(generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateHalfOpen:
+		if err != nil {
+			b.transition(StateOpen)
+			return
+		}
+		b.probeSuccesses++
+		if b.probeSuccesses >= b.cfg.HalfOpenRequests {
+			b.transition(StateClosed)
+		}
+	case StateClosed:
+		b.requests++
+		if err == nil {
+			b.consecutiveFailures = 0
+			return
+		}
+		b.failures++
+		b.consecutiveFailures++
+
+		if b.cfg.ConsecutiveFailures > 0 && b.consecutiveFailures >= b.cfg.ConsecutiveFailures {
+			b.transition(StateOpen)
+			return
+		}
+		if b.cfg.FailureRate > 0 && b.requests >= b.cfg.MinRequests &&
+			float64(b.failures)/float64(b.requests) >= b.cfg.FailureRate {
+			b.transition(StateOpen)
+		}
+	}
+}
+
+// refresh moves an open breaker to half-open after the cool-down and resets the closed window counts
+func (b *Breaker) refresh() {
+	now := b.now()
+
+	switch b.state {
+	case StateOpen:
+		if now.Sub(b.openedAt) >= b.cfg.CoolDown {
+			b.transition(StateHalfOpen)
+		}
+	case StateClosed:
+		if b.cfg.Window > 0 && now.Sub(b.windowStart) >= b.cfg.Window {
+			b.resetCounts()
+		}
+	}
+}
+
+func (b *Breaker) transition(state State) {
+	log := logger.New("nats", "breaker.transition")
+	log.AddMeta("breaker", b.name)
+	log.AddMeta("from", b.state.String())
+	log.AddMeta("to", state.String())
+	if state == StateOpen {
+		log.Warn("Circuit breaker state changed")
+	} else {
+		log.Info("Circuit breaker state changed")
+	}
+	metrics.CounterVec("nats_circuit_breaker_transitions_total").Add(b.name+":"+state.String(), 1)
+
+	b.state = state
+	b.generation++
+	b.resetCounts()
+	if state == StateOpen {
+		b.openedAt = b.now()
+	}
+}
+
+func (b *Breaker) resetCounts() {
+	b.windowStart = b.now()
+	b.requests, b.failures, b.consecutiveFailures = 0, 0, 0
+	b.probes, b.probeSuccesses = 0, 0
+}
@@ -0,0 +1,150 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ventive/go-mono-template/pkg/nats"
+)
+
+var errTest = errors.New("unit-tests")
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func newTestBreaker(cfg Config) (*Breaker, *clock) {
+	c := &clock{t: time.Unix(0, 0)}
+	b := New("unit-tests", cfg)
+	b.now = c.now
+	b.windowStart = c.t
+
+	return b, c
+}
+
+func call(b *Breaker, err error) error {
+	done, allowErr := b.Allow()
+	if allowErr != nil {
+		return allowErr
+	}
+	done(err)
+
+	return err
+}
+
+func TestBreaker(t *testing.T) {
+	t.Run("opensAfterConsecutiveFailures", func(t *testing.T) {
+		b, _ := newTestBreaker(Config{ConsecutiveFailures: 2, CoolDown: time.Second})
+
+		_ = call(b, errTest)
+		_ = call(b, nil)
+		_ = call(b, errTest)
+		assert.Equal(t, StateClosed, b.State())
+		_ = call(b, errTest)
+		assert.Equal(t, StateOpen, b.State())
+
+		err := call(b, nil)
+		var openErr *OpenError
+		assert.True(t, errors.As(err, &openErr))
+		assert.True(t, errors.Is(err, ErrOpen))
+		assert.Equal(t, time.Second, openErr.RetryAfter)
+	})
+
+	t.Run("opensOnFailureRate", func(t *testing.T) {
+		b, _ := newTestBreaker(Config{FailureRate: 0.5, MinRequests: 4, CoolDown: time.Second})
+
+		_ = call(b, errTest)
+		_ = call(b, nil)
+		_ = call(b, nil)
+		assert.Equal(t, StateClosed, b.State())
+		_ = call(b, errTest)
+		assert.Equal(t, StateOpen, b.State())
+	})
+
+	t.Run("windowResetsCounts", func(t *testing.T) {
+		b, c := newTestBreaker(Config{FailureRate: 0.5, MinRequests: 2, Window: time.Minute})
+
+		_ = call(b, errTest)
+		c.t = c.t.Add(time.Minute)
+		_ = call(b, nil)
+		_ = call(b, nil)
+		assert.Equal(t, StateClosed, b.State())
+	})
+
+	t.Run("halfOpenProbes", func(t *testing.T) {
+		b, c := newTestBreaker(Config{ConsecutiveFailures: 1, CoolDown: time.Second, HalfOpenRequests: 2})
+
+		_ = call(b, errTest)
+		c.t = c.t.Add(time.Second)
+		assert.Equal(t, StateHalfOpen, b.State())
+
+		// a failed probe opens the breaker again
+		_ = call(b, errTest)
+		assert.Equal(t, StateOpen, b.State())
+		c.t = c.t.Add(time.Second)
+
+		done1, err := b.Allow()
+		assert.Nil(t, err)
+		done2, err := b.Allow()
+		assert.Nil(t, err)
+		// only HalfOpenRequests probes are let through
+		_, err = b.Allow()
+		assert.True(t, errors.Is(err, ErrOpen))
+
+		done1(nil)
+		assert.Equal(t, StateHalfOpen, b.State())
+		done2(nil)
+		assert.Equal(t, StateClosed, b.State())
+	})
+
+	t.Run("ignoresCallsOfPreviousState", func(t *testing.T) {
+		b, c := newTestBreaker(Config{ConsecutiveFailures: 1, CoolDown: time.Second})
+
+		// allowed while closed, it finishes once the breaker is half-open
+		slow, err := b.Allow()
+		assert.Nil(t, err)
+		_ = call(b, errTest)
+		c.t = c.t.Add(time.Second)
+		assert.Equal(t, StateHalfOpen, b.State())
+
+		slow(nil)
+		assert.Equal(t, StateHalfOpen, b.State())
+		slow(errTest)
+		assert.Equal(t, StateHalfOpen, b.State())
+
+		_ = call(b, nil)
+		assert.Equal(t, StateClosed, b.State())
+	})
+}
+
+type fakeClient struct {
+	nats.Client
+	calls int
+}
+
+func (f *fakeClient) PublishMsg(_ *nats.Msg) error {
+	f.calls++
+	return errTest
+}
+
+func TestClient_PublishMsgWithRetries(t *testing.T) {
+	fake := &fakeClient{}
+	c := NewClient(fake, Config{Subjects: []string{"unit-tests.>"}, ConsecutiveFailures: 1, CoolDown: time.Minute})
+
+	// the breaker opens on the first failure, the retries fail fast
+	i, err := c.PublishMsgWithRetries(nats.NewMsg("unit-tests.a"), 3)
+	assert.True(t, errors.Is(err, ErrOpen))
+	assert.Equal(t, 2, i)
+	assert.Equal(t, 1, fake.calls)
+
+	// other subjects share nothing with the open breaker
+	err = c.PublishMsg(nats.NewMsg("other"))
+	assert.Equal(t, errTest, err)
+	assert.Equal(t, 2, fake.calls)
+}
@@ -0,0 +1,118 @@
+package breaker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ventive/go-mono-template/pkg/nats"
+)
+
+type client struct {
+	nats.Client
+	patterns []string
+	breakers map[string]*Breaker
+}
+
+// NewClient returns a nats.Client protecting the publishes and requests made to the
+// configured subjects with a breaker per subject pattern.
+// While a breaker is open the calls fail fast with an *OpenError and are not retried.
+func NewClient(c nats.Client, cfg Config) nats.Client {
+	breakers := make(map[string]*Breaker, len(cfg.Subjects))
+	for _, subject := range cfg.Subjects {
+		breakers[subject] = New(subject, cfg)
+	}
+
+	return &client{Client: c, patterns: cfg.Subjects, breakers: breakers}
+}
+
+// breaker returns the breaker protecting subject, or nil when the subject is not protected
+func (c *client) breaker(subject string) *Breaker {
+	for _, pattern := range c.patterns {
+		if nats.SubjectMatches(pattern, subject) {
+			return c.breakers[pattern]
+		}
+	}
+
+	return nil
+}
+
+// call runs fn through the breaker of subject
+func (c *client) call(subject string, fn func() error) error {
+	b := c.breaker(subject)
+	if b == nil {
+		return fn()
+	}
+
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	done(err)
+
+	return err
+}
+
+// retriable stops the retries as soon as the breaker is open
+func retriable(err error) error {
+	if errors.Is(err, ErrOpen) {
+		return nats.Permanent(err)
+	}
+
+	return err
+}
+
+// Publish godoc
+func (c *client) Publish(subject string, data []byte) error {
+	return c.call(subject, func() error {
+		return c.Client.Publish(subject, data)
+	})
+}
+
+// PublishWithRetries godoc
+func (c *client) PublishWithRetries(subject string, data []byte, retries int) (int, error) {
+	return nats.Retry(retries, func() error {
+		return retriable(c.Publish(subject, data))
+	})
+}
+
+// PublishMsg godoc
+func (c *client) PublishMsg(msg *nats.Msg) error {
+	return c.call(msg.Subject, func() error {
+		return c.Client.PublishMsg(msg)
+	})
+}
+
+// PublishMsgWithRetries godoc
+func (c *client) PublishMsgWithRetries(msg *nats.Msg, retries int) (int, error) {
+	return nats.Retry(retries, func() error {
+		return retriable(c.PublishMsg(msg))
+	})
+}
+
+// RequestMsg godoc
+func (c *client) RequestMsg(msg *nats.Msg, timeout time.Duration) (*nats.Msg, error) {
+	var resp *nats.Msg
+	err := c.call(msg.Subject, func() error {
+		var err error
+		resp, err = c.Client.RequestMsg(msg, timeout)
+		return err
+	})
+
+	return resp, err
+}
+
+// RequestMsgWithRetries godoc
+func (c *client) RequestMsgWithRetries(msg *nats.Msg, timeout time.Duration, retries int) (*nats.Msg, int, error) {
+	var resp *nats.Msg
+	i, err := nats.Retry(retries, func() error {
+		var err error
+		resp, err = c.RequestMsg(msg, timeout)
+		return retriable(err)
+	})
+	if err != nil {
+		return nil, i, err
+	}
+
+	return resp, i, nil
+}
@@ -0,0 +1,27 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOpen is matched by the errors returned while a breaker is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+// OpenError is returned when a call fails fast because its breaker is open
+type OpenError struct {
+	// Name of the breaker, the subject pattern for the client breakers
+	Name string
+	// RetryAfter is the remaining cool-down, zero while half-open
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s: %s, retry after %s", ErrOpen, e.Name, e.RetryAfter)
+}
+
+// Unwrap godoc
+func (e *OpenError) Unwrap() error {
+	return ErrOpen
+}
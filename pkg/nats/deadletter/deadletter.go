@@ -0,0 +1,145 @@
+// Package deadletter keeps the messages that could not be processed in a JetStream stream,
+// so that they can be listed, inspected and re-driven to their original subject.
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/ventive/go-mono-template/pkg/nats"
+)
+
+// RedriveHeader is set to the stream sequence of the dead letter on the re-driven messages
+const RedriveHeader = "X-Dead-Letter-Redrive"
+
+// Message is a dead letter: the original message and why it could not be processed
+type Message struct {
+	Subject string      `json:"subject"`
+	Header  nats.Header `json:"header,omitempty"`
+	Data    []byte      `json:"data,omitempty"`
+	// Errors of every attempt, the first one first
+	Errors         []string  `json:"errors"`
+	Attempts       int       `json:"attempts"`
+	FirstAttemptAt time.Time `json:"first_attempt_at"`
+	LastAttemptAt  time.Time `json:"last_attempt_at"`
+	// Source is the service that gave up on the message
+	Source string `json:"source,omitempty"`
+}
+
+// NewMsg encodes m in a message published to subject
+func NewMsg(subject string, m Message) (*nats.Msg, error) {
+	msg := nats.NewMsg(subject)
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	msg.Data = data
+
+	return msg, nil
+}
+
+// Entry is a dead letter stored in the stream
+type Entry struct {
+	Sequence uint64    `json:"sequence"`
+	StoredAt time.Time `json:"stored_at"`
+	Message
+}
+
+// Queue reads the dead letters of a stream
+type Queue struct {
+	stream jetstream.Stream
+}
+
+// NewQueue returns the queue of the stream capturing subject, the stream is created when missing
+func NewQueue(ctx context.Context, conn *natsgo.Conn, stream, subject string) (*Queue, error) {
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := js.Stream(ctx, stream)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		s, err = js.CreateStream(ctx, jetstream.StreamConfig{
+			Name:        stream,
+			Description: "dead letters",
+			Subjects:    []string{subject},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Queue{stream: s}, nil
+}
+
+// List returns at most limit dead letters, the oldest first, starting at sequence from.
+// Every dead letter is returned when limit <= 0.
+func (q *Queue) List(ctx context.Context, from uint64, limit int) ([]Entry, error) {
+	info, err := q.stream.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || uint64(limit) > info.State.Msgs {
+		limit = int(info.State.Msgs)
+	}
+
+	entries := make([]Entry, 0, limit)
+	for seq := max(from, info.State.FirstSeq); seq <= info.State.LastSeq && len(entries) < limit; seq++ {
+		entry, err := q.Get(ctx, seq)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Get returns the dead letter stored at seq
+func (q *Queue) Get(ctx context.Context, seq uint64) (Entry, error) {
+	raw, err := q.stream.GetMsg(ctx, seq)
+	if errors.Is(err, jetstream.ErrMsgNotFound) {
+		return Entry{}, ErrNotFound
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{Sequence: raw.Sequence, StoredAt: raw.Time}
+	if err := json.Unmarshal(raw.Data, &entry.Message); err != nil {
+		return Entry{}, err
+	}
+
+	return entry, nil
+}
+
+// Redrive publishes the dead letter stored at seq to its original subject with its original
+// headers and data, then removes it from the stream
+func (q *Queue) Redrive(ctx context.Context, publish func(*nats.Msg) error, seq uint64) (Entry, error) {
+	entry, err := q.Get(ctx, seq)
+	if err != nil {
+		return entry, err
+	}
+
+	msg := nats.NewMsg(entry.Subject)
+	for k, v := range entry.Header {
+		msg.Header[k] = v
+	}
+	msg.Header.Set(RedriveHeader, strconv.FormatUint(seq, 10))
+	msg.Data = entry.Data
+
+	if err := publish(msg); err != nil {
+		return entry, err
+	}
+
+	return entry, q.stream.DeleteMsg(ctx, seq)
+}
@@ -0,0 +1,75 @@
+package deadletter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/ventive/go-mono-template/pkg/nats"
+)
+
+func TestQueue(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	s := natsserver.RunServer(&opts)
+	defer s.Shutdown()
+
+	nc, err := natsgo.Connect(s.ClientURL())
+	assert.Nil(t, err)
+	defer nc.Close()
+
+	ctx := context.Background()
+	q, err := NewQueue(ctx, nc, "DEAD_LETTERS", "unit-tests.dead-letters")
+	assert.Nil(t, err)
+
+	for _, subject := range []string{"unit-tests.a", "unit-tests.b", "unit-tests.c"} {
+		msg, err := NewMsg("unit-tests.dead-letters", Message{
+			Subject:  subject,
+			Header:   nats.Header{"X-Id": {subject}},
+			Data:     []byte(subject),
+			Errors:   []string{"failed"},
+			Attempts: 1,
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, nc.PublishMsg(msg))
+	}
+	assert.Nil(t, nc.Flush())
+	assert.Eventually(t, func() bool {
+		entries, _ := q.List(ctx, 0, 0)
+		return len(entries) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	t.Run("list", func(t *testing.T) {
+		entries, err := q.List(ctx, 2, 1)
+		assert.Nil(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, uint64(2), entries[0].Sequence)
+		assert.Equal(t, "unit-tests.b", entries[0].Subject)
+	})
+
+	t.Run("redrive", func(t *testing.T) {
+		sub, err := nc.SubscribeSync("unit-tests.a")
+		assert.Nil(t, err)
+
+		entry, err := q.Redrive(ctx, nc.PublishMsg, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, "unit-tests.a", entry.Subject)
+
+		msg, err := sub.NextMsg(time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, "unit-tests.a", string(msg.Data))
+		assert.Equal(t, "unit-tests.a", msg.Header.Get("X-Id"))
+		assert.Equal(t, "1", msg.Header.Get(RedriveHeader))
+
+		_, err = q.Get(ctx, 1)
+		assert.Equal(t, ErrNotFound, err)
+		entries, err := q.List(ctx, 0, 0)
+		assert.Nil(t, err)
+		assert.Len(t, entries, 2)
+	})
+}
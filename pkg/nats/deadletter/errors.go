@@ -0,0 +1,6 @@
+package deadletter
+
+import "errors"
+
+// ErrNotFound is returned for the sequences which are not dead letters of the queue
+var ErrNotFound = errors.New("dead letter not found")
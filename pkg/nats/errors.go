@@ -1,13 +1,88 @@
 package nats
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const (
+	// ErrorHeader is set on the messages reporting an error
+	ErrorHeader = "X-Error"
+	// ErrorCodeHeader is set to the error code of the standard error messages
+	ErrorCodeHeader = "X-Error-Code"
+)
+
+// Headers of the requests not copied to the error messages: the ones of the binary CloudEvents describe
+// the data of the request rather than the error, the authorization one carries its credentials
+const (
+	contentTypeHeader   = "content-type"
+	cloudEventsPrefix   = "ce-"
+	authorizationHeader = "authorization"
+)
+
+// Error codes of the standard error replies
+const (
+	ErrorCodeInternal           = "internal_error"
+	ErrorCodeRateLimited        = "rate_limited"
+	ErrorCodeUnauthorized       = "unauthorized"
+	ErrorCodeForbidden          = "forbidden"
+	ErrorCodeValidation         = "validation_failed"
+	ErrorCodeUnsupportedVersion = "unsupported_version"
+)
 
 var (
 	ErrNATSNotConnected              = errors.New("nats not connected")
 	ErrNATSServerHeadersNotSupported = errors.New("nats server headers not supported")
+	ErrTLSCertKeyMismatch            = errors.New("tls cert and key must be set together")
+	ErrTLSUnknownVersion             = errors.New("unknown tls version")
+	ErrTLSUnknownCipherSuite         = errors.New("unknown tls cipher suite")
+	ErrTLSLoadFailed                 = errors.New("could not load tls files")
 )
 
 // Error godoc
 type Error struct {
+	Message string       `json:"message"`
+	Code    string       `json:"code,omitempty"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError describes why a field of a message is not valid
+type FieldError struct {
+	// Field path, empty when the error is not tied to a field
+	Field string `json:"field,omitempty"`
+	// Tag is the failed validation rule (e.g. required) or decode
+	Tag string `json:"tag"`
+	// Param of the validation rule, e.g. 0 for gte=0
+	Param   string `json:"param,omitempty"`
 	Message string `json:"message"`
 }
+
+// NewErrorMsg creates the standard error message: an Error payload with
+// the given headers copied, except the CloudEvents, content-type and authorization ones, and ErrorHeader set to message
+func NewErrorMsg(subject string, header Header, code, message string) *Msg {
+	return newErrorMsg(subject, header, Error{Message: message, Code: code})
+}
+
+// NewValidationErrorMsg creates the standard error message listing the invalid fields
+func NewValidationErrorMsg(subject string, header Header, message string, fields []FieldError) *Msg {
+	return newErrorMsg(subject, header, Error{Message: message, Code: ErrorCodeValidation, Fields: fields})
+}
+
+func newErrorMsg(subject string, header Header, e Error) *Msg {
+	msg := NewMsg(subject)
+	for k, v := range header {
+		lower := strings.ToLower(k)
+		if lower == contentTypeHeader || lower == authorizationHeader || strings.HasPrefix(lower, cloudEventsPrefix) {
+			continue
+		}
+		msg.Header[k] = v
+	}
+	msg.Header.Set(ErrorHeader, e.Message)
+	if e.Code != "" {
+		msg.Header.Set(ErrorCodeHeader, e.Code)
+	}
+	msg.Data, _ = json.Marshal(e)
+
+	return msg
+}
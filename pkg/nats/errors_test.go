@@ -0,0 +1,33 @@
+package nats
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewErrorMsg(t *testing.T) {
+	header := Header{
+		"X-Request-Id":   {"req-1"},
+		"Ce-Id":          {"evt-1"},
+		"ce-type":        {"adder.add"},
+		"Content-Type":   {"application/json"},
+		"Authorization":  {"Bearer token"},
+		"X-Deadline":     {"2026-01-01T00:00:00Z"},
+		ErrorCodeHeader:  {"stale"},
+		"Ce-Specversion": {"1.0"},
+	}
+
+	msg := NewErrorMsg("errors.unit-tests", header, ErrorCodeInternal, "failed")
+
+	assert.Equal(t, Header{
+		"X-Request-Id":  {"req-1"},
+		"X-Deadline":    {"2026-01-01T00:00:00Z"},
+		ErrorHeader:     {"failed"},
+		ErrorCodeHeader: {ErrorCodeInternal},
+	}, msg.Header)
+	var e Error
+	assert.Nil(t, json.Unmarshal(msg.Data, &e))
+	assert.Equal(t, Error{Message: "failed", Code: ErrorCodeInternal}, e)
+}
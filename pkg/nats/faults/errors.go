@@ -0,0 +1,6 @@
+package faults
+
+import "errors"
+
+// ErrInjected is returned by the publishes failed on purpose
+var ErrInjected = errors.New("injected fault")
@@ -0,0 +1,221 @@
+// Package faults wraps a nats.Client to inject failures for testing how services behave
+// when publishes fail, requests time out or messages are delayed, dropped, duplicated or reordered.
+//
+// It must not be used in production.
+package faults
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/ventive/go-mono-template/pkg/nats"
+)
+
+// Config of the injected faults
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Seed of the random number generator, the same seed gives the same sequence of faults
+	Seed  uint64 `mapstructure:"seed"`
+	Rules []Rule `mapstructure:"rules"`
+}
+
+// Rule describes the faults injected for the subjects matching Subject.
+// Rates are probabilities between 0 and 1.
+type Rule struct {
+	// Subject pattern, wildcards are supported
+	Subject string `mapstructure:"subject"`
+	// ErrorRate of publishes returning ErrInjected and requests timing out
+	ErrorRate float64 `mapstructure:"error_rate"`
+	// DropRate of messages silently dropped
+	DropRate float64 `mapstructure:"drop_rate"`
+	// DuplicateRate of messages delivered twice
+	DuplicateRate float64 `mapstructure:"duplicate_rate"`
+	// ReorderRate of messages held back and delivered after the next message on the same subject
+	ReorderRate float64 `mapstructure:"reorder_rate"`
+	// Latency added to every message, plus a random duration up to Jitter
+	Latency time.Duration `mapstructure:"latency"`
+	Jitter  time.Duration `mapstructure:"jitter"`
+}
+
+type client struct {
+	nats.Client
+	rules []Rule
+
+	mu   sync.Mutex
+	rng  *rand.Rand
+	held map[string]func()
+}
+
+// NewClient returns a nats.Client injecting the configured faults in the calls made to c.
+// The first rule matching a subject applies, subjects without rules are not affected.
+func NewClient(c nats.Client, cfg Config) nats.Client {
+	return &client{
+		Client: c,
+		rules:  cfg.Rules,
+		rng:    rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+		held:   make(map[string]func()),
+	}
+}
+
+// fault is the outcome drawn for a single message
+type fault struct {
+	err       bool
+	drop      bool
+	duplicate bool
+	reorder   bool
+	delay     time.Duration
+}
+
+func (c *client) draw(subject string) fault {
+	for _, rule := range c.rules {
+		if !nats.SubjectMatches(rule.Subject, subject) {
+			continue
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		f := fault{
+			err:       c.rng.Float64() < rule.ErrorRate,
+			drop:      c.rng.Float64() < rule.DropRate,
+			duplicate: c.rng.Float64() < rule.DuplicateRate,
+			reorder:   c.rng.Float64() < rule.ReorderRate,
+			delay:     rule.Latency,
+		}
+		if rule.Jitter > 0 {
+			f.delay += time.Duration(c.rng.Int64N(int64(rule.Jitter)))
+		}
+
+		return f
+	}
+
+	return fault{}
+}
+
+// deliver calls send according to the fault drawn for subject
+func (c *client) deliver(subject string, send func() error) error {
+	f := c.draw(subject)
+	time.Sleep(f.delay)
+
+	if f.err {
+		return ErrInjected
+	}
+	if f.drop {
+		return nil
+	}
+
+	c.mu.Lock()
+	previous := c.held[subject]
+	delete(c.held, subject)
+	if f.reorder && previous == nil {
+		c.held[subject] = func() {
+			_ = send()
+		}
+		c.mu.Unlock()
+
+		return nil
+	}
+	c.mu.Unlock()
+
+	err := send()
+	if f.duplicate && err == nil {
+		err = send()
+	}
+	if previous != nil {
+		previous()
+	}
+
+	return err
+}
+
+func (c *client) wrapHandler(handler natsgo.MsgHandler) natsgo.MsgHandler {
+	return func(msg *natsgo.Msg) {
+		_ = c.deliver(msg.Subject, func() error {
+			handler(msg)
+			return nil
+		})
+	}
+}
+
+// Subscribe injects delays, drops, duplicates and reordering in the received messages
+func (c *client) Subscribe(queue string, handler natsgo.MsgHandler) (*nats.Subscription, error) {
+	return c.Client.Subscribe(queue, c.wrapHandler(handler))
+}
+
+// QueueSubscribe injects delays, drops, duplicates and reordering in the received messages
+func (c *client) QueueSubscribe(queue, name string, handler natsgo.MsgHandler) (*nats.Subscription, error) {
+	return c.Client.QueueSubscribe(queue, name, c.wrapHandler(handler))
+}
+
+// Publish injects the faults before publishing
+func (c *client) Publish(subject string, data []byte) error {
+	return c.deliver(subject, func() error {
+		return c.Client.Publish(subject, data)
+	})
+}
+
+// PublishWithRetries retries the publishes with injected faults
+func (c *client) PublishWithRetries(subject string, data []byte, retries int) (int, error) {
+	return nats.Retry(retries, func() error {
+		return c.Publish(subject, data)
+	})
+}
+
+// PublishMsg injects the faults before publishing
+func (c *client) PublishMsg(msg *nats.Msg) error {
+	return c.deliver(msg.Subject, func() error {
+		return c.Client.PublishMsg(msg)
+	})
+}
+
+// PublishMsgWithRetries retries the publishes with injected faults
+func (c *client) PublishMsgWithRetries(msg *nats.Msg, retries int) (int, error) {
+	return nats.Retry(retries, func() error {
+		return c.PublishMsg(msg)
+	})
+}
+
+// RequestMsg delays the request and makes it time out when an error or a drop is injected
+func (c *client) RequestMsg(msg *nats.Msg, timeout time.Duration) (*nats.Msg, error) {
+	f := c.draw(msg.Subject)
+	if f.err || f.drop {
+		time.Sleep(timeout)
+
+		return nil, natsgo.ErrTimeout
+	}
+
+	time.Sleep(f.delay)
+
+	return c.Client.RequestMsg(msg, timeout)
+}
+
+// RequestMsgWithRetries retries the requests with injected faults
+func (c *client) RequestMsgWithRetries(msg *nats.Msg, timeout time.Duration, retries int) (*nats.Msg, int, error) {
+	var responseMsg *nats.Msg
+	i, err := nats.Retry(retries, func() error {
+		var err error
+		responseMsg, err = c.RequestMsg(msg, timeout)
+		return err
+	})
+	if err != nil {
+		return nil, i, err
+	}
+
+	return responseMsg, i, nil
+}
+
+// Close delivers the messages held back for reordering and closes the connection
+func (c *client) Close() {
+	c.mu.Lock()
+	held := c.held
+	c.held = make(map[string]func())
+	c.mu.Unlock()
+
+	for _, send := range held {
+		send()
+	}
+
+	c.Client.Close()
+}
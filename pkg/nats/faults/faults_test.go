@@ -0,0 +1,77 @@
+package faults
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ventive/go-mono-template/pkg/nats"
+)
+
+type fakeClient struct {
+	nats.Client
+	published []string
+}
+
+func (f *fakeClient) Publish(_ string, data []byte) error {
+	f.published = append(f.published, string(data))
+	return nil
+}
+
+func (f *fakeClient) Close() {}
+
+func publish(c nats.Client, subject string, data ...string) []error {
+	errs := make([]error, 0, len(data))
+	for _, d := range data {
+		errs = append(errs, c.Publish(subject, []byte(d)))
+	}
+
+	return errs
+}
+
+func TestClient_Publish(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		subject string
+		want    []string
+		wantErr error
+	}{
+		{"no matching rule", Rule{Subject: "other", ErrorRate: 1}, "unit-tests", []string{"1", "2"}, nil},
+		{"errors", Rule{Subject: "unit-tests", ErrorRate: 1}, "unit-tests", []string{}, ErrInjected},
+		{"drops", Rule{Subject: ">", DropRate: 1}, "unit-tests", []string{}, nil},
+		{"duplicates", Rule{Subject: ">", DuplicateRate: 1}, "unit-tests", []string{"1", "1", "2", "2"}, nil},
+		{"reorders", Rule{Subject: ">", ReorderRate: 1}, "unit-tests", []string{"2", "1"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeClient{published: []string{}}
+			c := NewClient(fake, Config{Rules: []Rule{tt.rule}})
+
+			for _, err := range publish(c, tt.subject, "1", "2") {
+				assert.Equal(t, tt.wantErr, err)
+			}
+			assert.Equal(t, tt.want, fake.published)
+		})
+	}
+
+	t.Run("heldMessagesAreSentOnClose", func(t *testing.T) {
+		fake := &fakeClient{}
+		c := NewClient(fake, Config{Rules: []Rule{{Subject: ">", ReorderRate: 1}}})
+
+		publish(c, "unit-tests", "1")
+		assert.Empty(t, fake.published)
+		c.Close()
+		assert.Equal(t, []string{"1"}, fake.published)
+	})
+
+	t.Run("sameSeedSameFaults", func(t *testing.T) {
+		cfg := Config{Seed: 7, Rules: []Rule{{Subject: ">", DropRate: 0.5}}}
+		data := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}
+
+		first, second := &fakeClient{}, &fakeClient{}
+		publish(NewClient(first, cfg), "unit-tests", data...)
+		publish(NewClient(second, cfg), "unit-tests", data...)
+		assert.Equal(t, first.published, second.published)
+		assert.Less(t, len(first.published), len(data))
+	})
+}
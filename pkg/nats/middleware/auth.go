@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ventive/go-mono-template/pkg/auth"
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/metrics"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+// AuthorizationHeader carries the bearer token of a message
+const AuthorizationHeader = "Authorization"
+
+const bearerPrefix = "Bearer "
+
+// Auth verifies the bearer token of the Authorization header and authorizes its principal
+// against rules. The principal is then available to the handler with auth.FromContext.
+//
+// The Authorization header is kept on the message, so that its dead letters can be redriven,
+// and must be left out of the headers copied to the outgoing messages, as the standard error
+// messages of pkg/nats do. Rejected messages are not processed, publish
+// sends the standard unauthorized or forbidden error to their reply subject and they are
+// counted per subject in the nats_auth_rejected_total metric.
+func Auth(verifier *auth.Verifier, rules []auth.Rule, publish func(*nats.Msg) error) ContextMiddleware {
+	rejected := metrics.CounterVec("nats_auth_rejected_total")
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *nats.Msg) {
+			principal, err := authenticate(verifier, rules, msg)
+			if err != nil {
+				rejected.Add(msg.Subject, 1)
+				rejectUnauthorized(ctx, msg, err, publish)
+				return
+			}
+
+			next(auth.NewContext(ctx, principal), msg)
+		}
+	}
+}
+
+func authenticate(verifier *auth.Verifier, rules []auth.Rule, msg *nats.Msg) (*auth.Principal, error) {
+	header := msg.Header.Get(AuthorizationHeader)
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return nil, auth.ErrMissingToken
+	}
+
+	principal, err := verifier.Verify(strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix)))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.Authorize(rules, msg.Subject, principal); err != nil {
+		return nil, err
+	}
+
+	return principal, nil
+}
+
+func rejectUnauthorized(ctx context.Context, msg *nats.Msg, err error, publish func(*nats.Msg) error) {
+	log := logger.FromContext(ctx).WithAction("middleware.Auth")
+	log.AddMeta("reason", err.Error())
+	log.Warn("Message not authorized")
+
+	if msg.Reply == "" || publish == nil {
+		return
+	}
+
+	code, message := pkgnats.ErrorCodeUnauthorized, auth.ErrInvalidToken.Error()
+	switch {
+	case errors.Is(err, auth.ErrForbidden):
+		code, message = pkgnats.ErrorCodeForbidden, auth.ErrForbidden.Error()
+	case errors.Is(err, auth.ErrMissingToken):
+		message = auth.ErrMissingToken.Error()
+	}
+
+	reply := pkgnats.NewErrorMsg(msg.Reply, msg.Header, code, message)
+	if err := Respond(ctx, publish, reply); err != nil {
+		log.Error("error when publishing unauthorized reply", err)
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/ventive/go-mono-template/pkg/auth"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+	"github.com/ventive/go-mono-template/pkg/nats/deadletter"
+)
+
+func TestAuth(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret")
+	assert.Nil(t, os.WriteFile(secret, []byte("unit-tests"), 0o600))
+	verifier, err := auth.NewVerifier(auth.Config{Keys: []auth.KeyConfig{{Algorithm: auth.AlgorithmHS256, File: secret}}})
+	assert.Nil(t, err)
+	rules := []auth.Rule{{Subject: "auth.>", Scopes: []string{"add"}}}
+
+	token := func(scope string) string {
+		signed, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":   "caller",
+			"scope": scope,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		}).SignedString([]byte("unit-tests"))
+
+		return "Bearer " + signed
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      string
+	}{
+		{"authorized", token("add"), ""},
+		{"missing token", "", pkgnats.ErrorCodeUnauthorized},
+		{"invalid token", "Bearer invalid", pkgnats.ErrorCodeUnauthorized},
+		{"missing scope", token("subtract"), pkgnats.ErrorCodeForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &publisher{}
+			var principal *auth.Principal
+			handler := Auth(verifier, rules, p.Publish)(func(ctx context.Context, _ *nats.Msg) {
+				principal, _ = auth.FromContext(ctx)
+			})
+
+			msg := newMsg("auth.unit-tests", map[string]string{AuthorizationHeader: tt.authorization})
+			handler(context.Background(), msg)
+			assert.Equal(t, tt.authorization, msg.Header.Get(AuthorizationHeader))
+
+			if tt.wantCode == "" {
+				assert.Empty(t, p.msgs)
+				assert.Equal(t, "caller", principal.Subject)
+				return
+			}
+
+			assert.Nil(t, principal)
+			assert.Len(t, p.msgs, 1)
+			var got pkgnats.Error
+			assert.Nil(t, json.Unmarshal(p.msgs[0].Data, &got))
+			assert.Equal(t, tt.wantCode, got.Code)
+			assert.Empty(t, p.msgs[0].Header.Get(AuthorizationHeader))
+		})
+	}
+
+	t.Run("redrivenDeadLetter", func(t *testing.T) {
+		p := &publisher{}
+		var principals []*auth.Principal
+		handler := Chain{}.
+			Append(NameAuth, Auth(verifier, rules, p.Publish)).
+			Append(NameDeadLetter, DeadLetter("unit-tests", DeadLetterConfig{Subject: "dead-letters", MaxAttempts: 1}, p.Publish)).
+			Then(func(ctx context.Context, msg *nats.Msg) {
+				principal, _ := auth.FromContext(ctx)
+				principals = append(principals, principal)
+				_ = Respond(ctx, p.Publish, pkgnats.NewErrorMsg(msg.Reply, msg.Header, pkgnats.ErrorCodeInternal, "failed"))
+			})
+
+		handler(context.Background(), newMsg("auth.unit-tests", map[string]string{AuthorizationHeader: token("add")}))
+		assert.Len(t, p.msgs, 2)
+		var dead deadletter.Message
+		assert.Nil(t, json.Unmarshal(p.msgs[1].Data, &dead))
+
+		redriven := nats.NewMsg(dead.Subject)
+		redriven.Header = dead.Header
+		handler(context.Background(), redriven)
+
+		assert.Len(t, principals, 2)
+		for _, principal := range principals {
+			assert.NotNil(t, principal)
+		}
+	})
+}
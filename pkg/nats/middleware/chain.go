@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+// ErrUnknownMiddleware is returned when a chain names a middleware missing from the registry
+var ErrUnknownMiddleware = errors.New("unknown middleware")
+
+// Names of the middlewares registered by the services
+const (
+	NameRecover     = "recover"
+	NameTrace       = "trace"
+	NameLog         = "log"
+	NameRateLimit   = "rate_limit"
+	NameAuth        = "auth"
+	NameIdempotency = "idempotency"
+	NameDeadLetter  = "dead_letter"
+	NameValidate    = "validate"
+	NameShadow      = "shadow"
+)
+
+type link struct {
+	name       string
+	middleware ContextMiddleware
+}
+
+// Chain is an ordered list of middlewares. The first middleware is the outermost one:
+// it gets the message first and returns last.
+// The zero value is an empty chain and a Chain is never modified once built.
+type Chain struct {
+	links []link
+}
+
+// Append returns a copy of the chain with m added as its innermost middleware
+func (c Chain) Append(name string, m ContextMiddleware) Chain {
+	links := make([]link, 0, len(c.links)+1)
+	links = append(links, c.links...)
+
+	return Chain{links: append(links, link{name: name, middleware: m})}
+}
+
+// Extend returns a copy of the chain followed by the middlewares of other
+func (c Chain) Extend(other Chain) Chain {
+	links := make([]link, 0, len(c.links)+len(other.links))
+	links = append(links, c.links...)
+
+	return Chain{links: append(links, other.links...)}
+}
+
+// Then wraps handler with the middlewares of the chain
+func (c Chain) Then(handler Handler) Handler {
+	for i := len(c.links) - 1; i >= 0; i-- {
+		handler = c.links[i].middleware(handler)
+	}
+
+	return handler
+}
+
+// Names returns the names of the middlewares from the outermost to the innermost
+func (c Chain) Names() []string {
+	names := make([]string, 0, len(c.links))
+	for _, l := range c.links {
+		names = append(names, l.name)
+	}
+
+	return names
+}
+
+// String godoc
+func (c Chain) String() string {
+	return strings.Join(c.Names(), " -> ")
+}
+
+// ChainConfig selects the middlewares of the subscriptions by name, from the outermost to the innermost
+type ChainConfig struct {
+	// Global middlewares, applied to every subscription
+	Global []string `mapstructure:"global"`
+	// Subscriptions adds middlewares after the global ones, the first entry matching the subject applies
+	Subscriptions []SubscriptionChainConfig `mapstructure:"subscriptions"`
+}
+
+// SubscriptionChainConfig godoc
+type SubscriptionChainConfig struct {
+	// Subject pattern, with the * and > wildcards
+	Subject     string   `mapstructure:"subject"`
+	Middlewares []string `mapstructure:"middlewares"`
+}
+
+// Registry holds the middlewares that can be selected by name
+type Registry struct {
+	middlewares map[string]ContextMiddleware
+}
+
+// NewRegistry godoc
+func NewRegistry() *Registry {
+	return &Registry{middlewares: make(map[string]ContextMiddleware)}
+}
+
+// Register adds m under name. A nil m registers a disabled middleware,
+// known by the registry but left out of the chains.
+func (r *Registry) Register(name string, m ContextMiddleware) {
+	r.middlewares[name] = m
+}
+
+// Chain builds the chain of the named middlewares, in the given order
+func (r *Registry) Chain(names ...string) (Chain, error) {
+	var c Chain
+	for _, name := range names {
+		m, ok := r.middlewares[name]
+		if !ok {
+			return Chain{}, fmt.Errorf("%w: %q", ErrUnknownMiddleware, name)
+		}
+		if m != nil {
+			c = c.Append(name, m)
+		}
+	}
+
+	return c, nil
+}
+
+// ChainFor builds the chain of subject: the global middlewares of cfg followed by
+// the middlewares of the first subscription matching subject
+func (r *Registry) ChainFor(cfg ChainConfig, subject string) (Chain, error) {
+	names := cfg.Global
+	for _, s := range cfg.Subscriptions {
+		if pkgnats.SubjectMatches(s.Subject, subject) {
+			names = append(names[:len(names):len(names)], s.Middlewares...)
+			break
+		}
+	}
+
+	return r.Chain(names...)
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func recordOrder(name string, calls *[]string) ContextMiddleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *nats.Msg) {
+			*calls = append(*calls, name)
+			next(ctx, msg)
+			*calls = append(*calls, name+" done")
+		}
+	}
+}
+
+func TestChain_Then(t *testing.T) {
+	var calls []string
+	c := Chain{}.Append("first", recordOrder("first", &calls)).Append("second", recordOrder("second", &calls))
+
+	c.Then(func(_ context.Context, _ *nats.Msg) {
+		calls = append(calls, "handler")
+	})(context.Background(), nats.NewMsg("chain.unit-tests"))
+
+	assert.Equal(t, []string{"first", "second", "handler", "second done", "first done"}, calls)
+	assert.Equal(t, "first -> second", c.String())
+}
+
+func TestChain_Append(t *testing.T) {
+	var calls []string
+	base := Chain{}.Append("base", recordOrder("base", &calls))
+
+	a := base.Append("a", recordOrder("a", &calls))
+	b := base.Append("b", recordOrder("b", &calls))
+
+	assert.Equal(t, []string{"base"}, base.Names())
+	assert.Equal(t, []string{"base", "a"}, a.Names())
+	assert.Equal(t, []string{"base", "b"}, b.Names())
+	assert.Equal(t, []string{"base", "a", "base", "b"}, a.Extend(b).Names())
+}
+
+func TestRegistry_ChainFor(t *testing.T) {
+	var calls []string
+	r := NewRegistry()
+	r.Register(NameRecover, recordOrder(NameRecover, &calls))
+	r.Register(NameLog, recordOrder(NameLog, &calls))
+	r.Register(NameAuth, recordOrder(NameAuth, &calls))
+	r.Register(NameTrace, nil)
+
+	cfg := ChainConfig{
+		Global: []string{NameRecover, NameTrace, NameLog},
+		Subscriptions: []SubscriptionChainConfig{
+			{Subject: "service.*.inbox", Middlewares: []string{NameAuth}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		cfg     ChainConfig
+		subject string
+		want    []string
+		wantErr error
+	}{
+		{"global only", cfg, "other.subject", []string{NameRecover, NameLog}, nil},
+		{"subscription", cfg, "service.adder.inbox", []string{NameRecover, NameLog, NameAuth}, nil},
+		{"unknown", ChainConfig{Global: []string{"unknown"}}, "other.subject", nil, ErrUnknownMiddleware},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := r.ChainFor(tt.cfg, tt.subject)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ChainFor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, c.Names())
+			}
+		})
+	}
+	assert.Equal(t, []string{NameRecover, NameTrace, NameLog}, cfg.Global)
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DeadlineHeader holds the RFC 3339 time after which the caller is no longer interested in the result
+	DeadlineHeader = "X-Deadline"
+	// RequestIDHeader identifies a request across services. It is generated when missing.
+	RequestIDHeader = "X-Request-Id"
+)
+
+// Handler is a message handler receiving the context of the message
+type Handler func(ctx context.Context, msg *nats.Msg)
+
+// ContextMiddleware function signature for Handler
+type ContextMiddleware func(next Handler) Handler
+
+type requestIDKey struct{}
+
+// RequestID returns the request ID of the message processed under ctx
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+// NewMsgContext derives the context used to process msg from parent.
+//
+// The context expires at the time set in the DeadlineHeader and carries the request ID,
+// the trace context found in the headers and a logger for section with the message details.
+// The request ID is added to msg headers when it is missing, so it is forwarded to the output messages.
+func NewMsgContext(parent context.Context, section string, msg *nats.Msg) (context.Context, context.CancelFunc) {
+	if msg.Header == nil {
+		msg.Header = nats.Header{}
+	}
+
+	requestID := msg.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = nuid.Next()
+		msg.Header.Set(RequestIDHeader, requestID)
+	}
+
+	ctx := context.WithValue(parent, requestIDKey{}, requestID)
+	ctx = tracing.Extract(ctx, msg)
+
+	log := logger.New(section, "message")
+	log.AddMeta("subject", msg.Subject)
+	log.AddMeta("request_id", requestID)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		log.AddMeta("trace_id", spanContext.TraceID().String())
+	}
+	ctx = logger.NewContext(ctx, log)
+
+	if deadline := msg.Header.Get(DeadlineHeader); deadline != "" {
+		d, err := time.Parse(time.RFC3339Nano, deadline)
+		if err == nil {
+			return context.WithDeadline(ctx, d)
+		}
+		log.WithAction("middleware.NewMsgContext").Warn("Ignoring invalid " + DeadlineHeader + " header " + deadline)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// ToMsgHandler adapts handler to a nats.MsgHandler.
+// Every message is processed with a context derived from parent by NewMsgContext.
+func ToMsgHandler(parent context.Context, section string, handler Handler) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		ctx, cancel := NewMsgContext(parent, section, msg)
+		defer cancel()
+
+		handler(ctx, msg)
+	}
+}
+
+// FromMsgHandler adapts a nats.MsgHandler to a Handler ignoring the context
+func FromMsgHandler(handler nats.MsgHandler) Handler {
+	return func(_ context.Context, msg *nats.Msg) {
+		handler(msg)
+	}
+}
+
+// Adapt turns a Middleware into a ContextMiddleware, the context is passed through unchanged
+func Adapt(m Middleware) ContextMiddleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *nats.Msg) {
+			m(func(msg *nats.Msg) {
+				next(ctx, msg)
+			})(msg)
+		}
+	}
+}
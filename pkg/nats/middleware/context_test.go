@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/ventive/go-mono-template/pkg/logger"
+)
+
+func TestToMsgHandler(t *testing.T) {
+	t.Run("honorsDeadlineHeader", func(t *testing.T) {
+		deadline := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+		msg := nats.NewMsg("unit-tests")
+		msg.Header.Set(DeadlineHeader, deadline.Format(time.RFC3339Nano))
+
+		var got time.Time
+		ToMsgHandler(context.Background(), "unit-tests", func(ctx context.Context, _ *nats.Msg) {
+			got, _ = ctx.Deadline()
+		})(msg)
+		assert.True(t, deadline.Equal(got))
+	})
+
+	t.Run("ignoresInvalidDeadlineHeader", func(t *testing.T) {
+		msg := nats.NewMsg("unit-tests")
+		msg.Header.Set(DeadlineHeader, "tomorrow")
+
+		hasDeadline := true
+		ToMsgHandler(context.Background(), "unit-tests", func(ctx context.Context, _ *nats.Msg) {
+			_, hasDeadline = ctx.Deadline()
+		})(msg)
+		assert.False(t, hasDeadline)
+	})
+
+	t.Run("derivesFromParent", func(t *testing.T) {
+		parent, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var err error
+		ToMsgHandler(parent, "unit-tests", func(ctx context.Context, _ *nats.Msg) {
+			err = ctx.Err()
+		})(&nats.Msg{Subject: "unit-tests"})
+		assert.Equal(t, context.Canceled, err)
+	})
+
+	t.Run("carriesRequestIDAndLogger", func(t *testing.T) {
+		msg := &nats.Msg{Subject: "unit-tests"}
+
+		var requestID string
+		var log *logger.Logger
+		ToMsgHandler(context.Background(), "unit-tests", func(ctx context.Context, _ *nats.Msg) {
+			requestID = RequestID(ctx)
+			log = logger.FromContext(ctx)
+		})(msg)
+		assert.NotEmpty(t, requestID)
+		assert.Equal(t, requestID, msg.Header.Get(RequestIDHeader))
+		assert.NotNil(t, log)
+
+		ToMsgHandler(context.Background(), "unit-tests", func(ctx context.Context, _ *nats.Msg) {
+			assert.Equal(t, requestID, RequestID(ctx))
+		})(msg)
+	})
+}
+
+func TestAdapt(t *testing.T) {
+	type key struct{}
+	var order []string
+	m := func(next nats.MsgHandler) nats.MsgHandler {
+		return func(msg *nats.Msg) {
+			order = append(order, "middleware")
+			next(msg)
+		}
+	}
+
+	handler := Adapt(m)(func(ctx context.Context, _ *nats.Msg) {
+		order = append(order, ctx.Value(key{}).(string))
+	})
+	handler(context.WithValue(context.Background(), key{}, "handler"), nats.NewMsg("unit-tests"))
+	assert.Equal(t, []string{"middleware", "handler"}, order)
+
+	called := false
+	FromMsgHandler(func(_ *nats.Msg) { called = true })(context.Background(), nats.NewMsg("unit-tests"))
+	assert.True(t, called)
+}
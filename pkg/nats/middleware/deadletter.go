@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/metrics"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+	"github.com/ventive/go-mono-template/pkg/nats/deadletter"
+)
+
+// DefaultDeadLetterMaxAttempts is the number of attempts of the failed messages when not configured
+const DefaultDeadLetterMaxAttempts = 3
+
+// DeadLetterConfig godoc
+type DeadLetterConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Subject receiving the dead letters
+	Subject string `mapstructure:"subject"`
+	// Stream is the JetStream stream capturing Subject, created when missing
+	Stream string `mapstructure:"stream"`
+	// MaxAttempts before a failed message is dead-lettered, DefaultDeadLetterMaxAttempts when not set
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// Backoff before the second attempt, doubled for every next one
+	Backoff time.Duration `mapstructure:"backoff"`
+	// NonRetryableCodes are the error codes dead-lettered after the first attempt (e.g. validation_failed)
+	NonRetryableCodes []string `mapstructure:"non_retryable_codes"`
+}
+
+type retryKey struct{}
+
+type retryState struct {
+	attempt int
+	retry   bool
+	// held is the suppressed response, published when the retry is canceled
+	held    *nats.Msg
+	respond Responder
+}
+
+// WillRetry reports whether the failed message processed under ctx will be processed again.
+// Handlers use it to only report the failure of the last attempt.
+func WillRetry(ctx context.Context) bool {
+	state, ok := ctx.Value(retryKey{}).(*retryState)
+
+	return ok && state.retry
+}
+
+// isRetry reports whether ctx is the one of a second or later attempt of the message
+func isRetry(ctx context.Context) bool {
+	state, ok := ctx.Value(retryKey{}).(*retryState)
+
+	return ok && state.attempt > 1
+}
+
+// DeadLetter processes again the messages whose response, published with Respond, carries the
+// ErrorHeader, up to cfg.MaxAttempts times. Only the response of the last attempt is published.
+//
+// When every attempt failed, the original subject, headers and data of the message are published
+// to cfg.Subject with the error of every attempt, see deadletter.Message.
+// The dead letters are counted per subject in the nats_dead_letters_total metric.
+func DeadLetter(source string, cfg DeadLetterConfig, publish func(*nats.Msg) error) ContextMiddleware {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultDeadLetterMaxAttempts
+	}
+	deadLetters := metrics.CounterVec("nats_dead_letters_total")
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *nats.Msg) {
+			dead := deadletter.Message{
+				Subject:        msg.Subject,
+				Header:         copyHeader(msg.Header),
+				Data:           slices.Clone(msg.Data),
+				FirstAttemptAt: time.Now(),
+				Source:         source,
+			}
+			backoff := cfg.Backoff
+
+			for attempt := 1; ; attempt++ {
+				failure, state := "", &retryState{attempt: attempt}
+				next(context.WithValue(InterceptResponses(ctx, func(respond Responder) Responder {
+					return func(out *nats.Msg) error {
+						failure = out.Header.Get(pkgnats.ErrorHeader)
+						if failure != "" && attempt < maxAttempts &&
+							!slices.Contains(cfg.NonRetryableCodes, out.Header.Get(pkgnats.ErrorCodeHeader)) {
+							state.retry, state.held, state.respond = true, out, respond
+							return nil
+						}
+
+						return respond(out)
+					}
+				}), retryKey{}, state), msg)
+
+				if failure == "" {
+					return
+				}
+				dead.Errors = append(dead.Errors, failure)
+				dead.Attempts, dead.LastAttemptAt = attempt, time.Now()
+				if !state.retry {
+					break
+				}
+				if !wait(ctx, backoff) {
+					if err := state.respond(state.held); err != nil {
+						logger.FromContext(ctx).WithAction("middleware.DeadLetter").Error("error when publishing response", err)
+					}
+					break
+				}
+				backoff *= 2
+
+				msg.Header, msg.Data = copyHeader(dead.Header), slices.Clone(dead.Data)
+			}
+
+			deadLetters.Add(msg.Subject, 1)
+			publishDeadLetter(ctx, cfg.Subject, dead, publish)
+		}
+	}
+}
+
+// wait reports whether d elapsed before ctx is done
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func copyHeader(header nats.Header) nats.Header {
+	c := make(nats.Header, len(header))
+	for k, v := range header {
+		c[k] = slices.Clone(v)
+	}
+
+	return c
+}
+
+func publishDeadLetter(ctx context.Context, subject string, dead deadletter.Message, publish func(*nats.Msg) error) {
+	log := logger.FromContext(ctx).WithAction("middleware.DeadLetter")
+	log.AddMeta("attempts", dead.Attempts)
+	log.Warn("Dead-lettering message")
+
+	msg, err := deadletter.NewMsg(subject, dead)
+	if err == nil {
+		err = publish(msg)
+	}
+	if err != nil {
+		log.Error("error when publishing dead letter", err)
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+	"github.com/ventive/go-mono-template/pkg/nats/deadletter"
+)
+
+func TestDeadLetter(t *testing.T) {
+	cfg := DeadLetterConfig{
+		Subject:           "dead-letters",
+		MaxAttempts:       3,
+		NonRetryableCodes: []string{pkgnats.ErrorCodeValidation},
+	}
+
+	// failing returns a handler failing the first failures attempts with code
+	failing := func(failures int, code string, retries *[]bool) Handler {
+		attempt := 0
+		return func(ctx context.Context, msg *nats.Msg) {
+			attempt++
+			msg.Header.Set("X-Attempt", "changed")
+			out := nats.NewMsg(msg.Reply)
+			if attempt <= failures {
+				out = pkgnats.NewErrorMsg(msg.Reply, nil, code, "failed")
+			}
+			_ = Respond(ctx, func(m *nats.Msg) error { return nil }, out)
+			*retries = append(*retries, WillRetry(ctx))
+		}
+	}
+
+	tests := []struct {
+		name        string
+		failures    int
+		code        string
+		wantRetries []bool
+		wantReply   string
+		wantErrors  []string
+	}{
+		{"succeeds", 0, "", []bool{false}, "", nil},
+		{"succeeds after retries", 2, "", []bool{true, true, false}, "", nil},
+		{"dead-lettered after max attempts", 5, "", []bool{true, true, false}, "failed", []string{"failed", "failed", "failed"}},
+		{"non retryable", 5, pkgnats.ErrorCodeValidation, []bool{false}, "failed", []string{"failed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &publisher{}
+			var retries []bool
+			var replies []*nats.Msg
+			handler := DeadLetter("unit-tests", cfg, p.Publish)(failing(tt.failures, tt.code, &retries))
+
+			msg := newMsg("dead-letter.unit-tests", map[string]string{"X-Id": "1"})
+			msg.Data = []byte("data")
+			ctx := InterceptResponses(context.Background(), func(respond Responder) Responder {
+				return func(out *nats.Msg) error {
+					replies = append(replies, out)
+					return respond(out)
+				}
+			})
+			handler(ctx, msg)
+
+			assert.Equal(t, tt.wantRetries, retries)
+			assert.Len(t, replies, 1)
+			assert.Equal(t, tt.wantReply, replies[0].Header.Get(pkgnats.ErrorHeader))
+
+			if tt.wantErrors == nil {
+				assert.Empty(t, p.msgs)
+				return
+			}
+
+			assert.Len(t, p.msgs, 1)
+			assert.Equal(t, "dead-letters", p.msgs[0].Subject)
+			var got deadletter.Message
+			assert.Nil(t, json.Unmarshal(p.msgs[0].Data, &got))
+			assert.Equal(t, "dead-letter.unit-tests", got.Subject)
+			assert.Equal(t, pkgnats.Header{"X-Id": {"1"}}, got.Header)
+			assert.Equal(t, "data", string(got.Data))
+			assert.Equal(t, tt.wantErrors, got.Errors)
+			assert.Equal(t, len(tt.wantErrors), got.Attempts)
+			assert.Equal(t, "unit-tests", got.Source)
+			assert.False(t, got.LastAttemptAt.Before(got.FirstAttemptAt))
+		})
+	}
+}
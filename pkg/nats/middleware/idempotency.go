@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/metrics"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+const (
+	// IdempotencyKeyHeader identifies a request across its redeliveries, it takes precedence over MsgIDHeader
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// MsgIDHeader is the NATS message ID, also used by JetStream to deduplicate publishes
+	MsgIDHeader = "Nats-Msg-Id"
+	// IdempotentReplayHeader is set on the responses replayed for duplicated requests
+	IdempotentReplayHeader = "X-Idempotent-Replay"
+)
+
+// Idempotency stores
+const (
+	IdempotencyStoreMemory    = "memory"
+	IdempotencyStoreJetStream = "jetstream"
+)
+
+var (
+	// ErrInProgress is returned by IdempotencyStore.Reserve while another delivery of the request is processed
+	ErrInProgress = errors.New("request in progress")
+	// ErrInvalidTTL is returned for the stores configured without a positive TTL, which would keep nothing
+	ErrInvalidTTL = errors.New("invalid idempotency ttl")
+)
+
+// IdempotencyConfig godoc
+type IdempotencyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Store is memory (default), local to the instance, or jetstream, shared by all the instances
+	Store string `mapstructure:"store"`
+	// TTL of the stored responses, it must be positive
+	TTL time.Duration `mapstructure:"ttl"`
+	// MaxEntries bounds the memory store, the oldest responses are evicted first
+	MaxEntries int `mapstructure:"max_entries"`
+	// Bucket is the JetStream key-value bucket of the jetstream store, created when missing
+	Bucket string `mapstructure:"bucket"`
+}
+
+// StoredResponse is the first response published for a request
+type StoredResponse struct {
+	Header nats.Header `json:"header,omitempty"`
+	Data   []byte      `json:"data,omitempty"`
+	// Pending is set while the request is processed
+	Pending bool `json:"pending,omitempty"`
+}
+
+// IdempotencyStore keeps the responses of the requests for a TTL
+type IdempotencyStore interface {
+	// Reserve marks key in progress. It returns the stored response when key is completed,
+	// ErrInProgress when key is already reserved and nil when the caller got the reservation.
+	Reserve(ctx context.Context, key string) (*StoredResponse, error)
+	// Save completes key with its response
+	Save(ctx context.Context, key string, response *StoredResponse) error
+	// Release drops the reservation of key, so the next delivery of the request is processed
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotency processes once the messages with the same IdempotencyKeyHeader, or MsgIDHeader,
+// on the same subject.
+//
+// The first successful response published with Respond is saved in store and the duplicates are
+// answered with it by publish without calling the next handlers. Duplicates without a reply subject
+// are dropped, so their output is not published twice. Error responses are not saved: the next
+// delivery of a failed request is processed again. The messages without key are always processed,
+// as well as every message while the store fails.
+// Duplicates are counted per subject in the nats_idempotent_duplicates_total metric.
+func Idempotency(store IdempotencyStore, publish func(*nats.Msg) error) ContextMiddleware {
+	duplicates := metrics.CounterVec("nats_idempotent_duplicates_total")
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *nats.Msg) {
+			key := idempotencyKey(msg)
+			if key == "" {
+				next(ctx, msg)
+				return
+			}
+
+			log := logger.FromContext(ctx).WithAction("middleware.Idempotency")
+
+			stored, err := store.Reserve(ctx, key)
+			switch {
+			case errors.Is(err, ErrInProgress):
+				duplicates.Add(msg.Subject, 1)
+				log.Warn("Dropping duplicate of a request in progress")
+				return
+			case err != nil:
+				log.Error("error when reserving idempotency key, processing the message", err)
+				next(ctx, msg)
+				return
+			case stored != nil:
+				duplicates.Add(msg.Subject, 1)
+				replay(ctx, log, msg, stored, publish)
+				return
+			}
+
+			var response *StoredResponse
+			defer func() {
+				// the store is updated even when the message deadline is exceeded
+				ctx := context.WithoutCancel(ctx)
+				if response == nil {
+					err = store.Release(ctx, key)
+				} else {
+					err = store.Save(ctx, key, response)
+				}
+				if err != nil {
+					log.Error("error when completing idempotency key", err)
+				}
+			}()
+
+			next(InterceptResponses(ctx, func(respond Responder) Responder {
+				return func(out *nats.Msg) error {
+					err := respond(out)
+					if err == nil && response == nil && out.Header.Get(pkgnats.ErrorHeader) == "" {
+						response = &StoredResponse{Header: out.Header, Data: out.Data}
+					}
+
+					return err
+				}
+			}), msg)
+		}
+	}
+}
+
+// idempotencyKey hashes the key of msg with its subject, the result is a valid key-value store key
+func idempotencyKey(msg *nats.Msg) string {
+	key := msg.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		key = msg.Header.Get(MsgIDHeader)
+	}
+	if key == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(msg.Subject + "\x00" + key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func replay(ctx context.Context, log *logger.Logger, msg *nats.Msg, stored *StoredResponse, publish func(*nats.Msg) error) {
+	if msg.Reply == "" || publish == nil {
+		log.Info("Dropping duplicate of a processed message")
+		return
+	}
+
+	log.Info("Replaying response of a duplicated request")
+	reply := nats.NewMsg(msg.Reply)
+	for k, v := range stored.Header {
+		reply.Header[k] = v
+	}
+	reply.Header.Set(IdempotentReplayHeader, "true")
+	reply.Data = stored.Data
+
+	if err := Respond(ctx, publish, reply); err != nil {
+		log.Error("error when publishing replayed response", err)
+	}
+}
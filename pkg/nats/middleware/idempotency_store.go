@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type memoryEntry struct {
+	key      string
+	response *StoredResponse
+	expires  time.Time
+}
+
+type memoryIdempotencyStore struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order holds the entries from the oldest to the newest
+	order *list.List
+	now   func() time.Time
+}
+
+// NewMemoryIdempotencyStore returns a store local to the process holding at most maxEntries keys,
+// maxEntries <= 0 means unbounded
+func NewMemoryIdempotencyStore(ttl time.Duration, maxEntries int) IdempotencyStore {
+	return &memoryIdempotencyStore{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// Reserve godoc
+func (s *memoryIdempotencyStore) Reserve(_ context.Context, key string) (*StoredResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.evict(now)
+
+	if e, ok := s.entries[key]; ok {
+		entry := e.Value.(*memoryEntry)
+		if entry.response.Pending {
+			return nil, ErrInProgress
+		}
+
+		return entry.response, nil
+	}
+
+	s.entries[key] = s.order.PushBack(&memoryEntry{
+		key:      key,
+		response: &StoredResponse{Pending: true},
+		expires:  now.Add(s.ttl),
+	})
+
+	return nil, nil
+}
+
+// Save godoc
+func (s *memoryIdempotencyStore) Save(_ context.Context, key string, response *StoredResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		e = s.order.PushBack(&memoryEntry{key: key})
+		s.entries[key] = e
+	}
+	entry := e.Value.(*memoryEntry)
+	entry.response = response
+	entry.expires = s.now().Add(s.ttl)
+	s.order.MoveToBack(e)
+
+	return nil
+}
+
+// Release godoc
+func (s *memoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		s.order.Remove(e)
+		delete(s.entries, key)
+	}
+
+	return nil
+}
+
+// evict drops the expired entries and the oldest ones over maxEntries, keeping room for a new one
+func (s *memoryIdempotencyStore) evict(now time.Time) {
+	for e := s.order.Front(); e != nil; e = s.order.Front() {
+		entry := e.Value.(*memoryEntry)
+		if now.Before(entry.expires) && (s.maxEntries <= 0 || s.order.Len() < s.maxEntries) {
+			return
+		}
+		s.order.Remove(e)
+		delete(s.entries, entry.key)
+	}
+}
+
+type kvIdempotencyStore struct {
+	kv jetstream.KeyValue
+}
+
+// NewKVIdempotencyStore returns a store shared by the instances in the JetStream key-value bucket.
+// The bucket is created with ttl when missing.
+func NewKVIdempotencyStore(ctx context.Context, conn *nats.Conn, bucket string, ttl time.Duration) (IdempotencyStore, error) {
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	kv, err := js.KeyValue(ctx, bucket)
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+			Bucket:      bucket,
+			Description: "idempotency keys and responses",
+			TTL:         ttl,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &kvIdempotencyStore{kv: kv}, nil
+}
+
+var pendingResponse, _ = json.Marshal(StoredResponse{Pending: true})
+
+// Reserve godoc
+func (s *kvIdempotencyStore) Reserve(ctx context.Context, key string) (*StoredResponse, error) {
+	_, err := s.kv.Create(ctx, key, pendingResponse)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, jetstream.ErrKeyExists) {
+		return nil, err
+	}
+
+	entry, err := s.kv.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var response StoredResponse
+	if err := json.Unmarshal(entry.Value(), &response); err != nil {
+		return nil, err
+	}
+	if response.Pending {
+		return nil, ErrInProgress
+	}
+
+	return &response, nil
+}
+
+// Save godoc
+func (s *kvIdempotencyStore) Save(ctx context.Context, key string, response *StoredResponse) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.kv.Put(ctx, key, data)
+
+	return err
+}
+
+// Release godoc
+func (s *kvIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.kv.Delete(ctx, key)
+}
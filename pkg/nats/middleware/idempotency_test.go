@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+func TestIdempotency(t *testing.T) {
+	p := &publisher{}
+	calls := 0
+	handler := Chain{}.Append(NameIdempotency, Idempotency(NewMemoryIdempotencyStore(time.Minute, 10), p.Publish)).
+		Then(func(ctx context.Context, msg *nats.Msg) {
+			calls++
+			out := pkgnats.NewMsgWithHeaders(msg.Reply, map[string]string{"X-Call": "first"})
+			if msg.Header.Get("X-Fail") != "" {
+				out.Header.Set(pkgnats.ErrorHeader, "failed")
+			}
+			out.Data = []byte("result")
+			assert.Nil(t, Respond(ctx, p.Publish, out))
+		})
+
+	t.Run("replaysFirstResponse", func(t *testing.T) {
+		handler(context.Background(), newMsg("idempotency.unit-tests", map[string]string{IdempotencyKeyHeader: "1"}))
+		handler(context.Background(), newMsg("idempotency.unit-tests", map[string]string{IdempotencyKeyHeader: "1"}))
+
+		assert.Equal(t, 1, calls)
+		assert.Len(t, p.msgs, 2)
+		assert.Equal(t, "result", string(p.msgs[1].Data))
+		assert.Equal(t, "first", p.msgs[1].Header.Get("X-Call"))
+		assert.Equal(t, "true", p.msgs[1].Header.Get(IdempotentReplayHeader))
+	})
+
+	t.Run("dropsDuplicatesWithoutReply", func(t *testing.T) {
+		calls, p.msgs = 0, nil
+		msg := newMsg("idempotency.unit-tests", map[string]string{MsgIDHeader: "2"})
+		handler(context.Background(), msg)
+		msg.Reply = ""
+		handler(context.Background(), msg)
+
+		assert.Equal(t, 1, calls)
+		assert.Len(t, p.msgs, 1)
+	})
+
+	t.Run("keysAreScopedBySubject", func(t *testing.T) {
+		calls = 0
+		handler(context.Background(), newMsg("idempotency.a", map[string]string{IdempotencyKeyHeader: "3"}))
+		handler(context.Background(), newMsg("idempotency.b", map[string]string{IdempotencyKeyHeader: "3"}))
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("errorsAreNotStored", func(t *testing.T) {
+		calls = 0
+		headers := map[string]string{IdempotencyKeyHeader: "4", "X-Fail": "true"}
+		handler(context.Background(), newMsg("idempotency.unit-tests", headers))
+		handler(context.Background(), newMsg("idempotency.unit-tests", headers))
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("withoutKey", func(t *testing.T) {
+		calls = 0
+		handler(context.Background(), newMsg("idempotency.unit-tests", nil))
+		handler(context.Background(), newMsg("idempotency.unit-tests", nil))
+
+		assert.Equal(t, 2, calls)
+	})
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryIdempotencyStore(time.Minute, 2).(*memoryIdempotencyStore)
+	store.now = func() time.Time { return now }
+
+	got, err := store.Reserve(ctx, "a")
+	assert.Nil(t, got)
+	assert.Nil(t, err)
+	_, err = store.Reserve(ctx, "a")
+	assert.Equal(t, ErrInProgress, err)
+
+	assert.Nil(t, store.Save(ctx, "a", &StoredResponse{Data: []byte("a")}))
+	got, err = store.Reserve(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", string(got.Data))
+
+	t.Run("release", func(t *testing.T) {
+		_, _ = store.Reserve(ctx, "b")
+		assert.Nil(t, store.Release(ctx, "b"))
+		got, err := store.Reserve(ctx, "b")
+		assert.Nil(t, got)
+		assert.Nil(t, err)
+	})
+
+	t.Run("boundedSize", func(t *testing.T) {
+		_, _ = store.Reserve(ctx, "c")
+		assert.Equal(t, 2, store.order.Len())
+		got, err := store.Reserve(ctx, "a")
+		assert.Nil(t, got)
+		assert.Nil(t, err)
+	})
+
+	t.Run("expires", func(t *testing.T) {
+		assert.Nil(t, store.Save(ctx, "d", &StoredResponse{}))
+		now = now.Add(time.Minute)
+		got, err := store.Reserve(ctx, "d")
+		assert.Nil(t, got)
+		assert.Nil(t, err)
+	})
+}
+
+func TestKVIdempotencyStore(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	s := natsserver.RunServer(&opts)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(s.ClientURL())
+	assert.Nil(t, err)
+	defer nc.Close()
+
+	ctx := context.Background()
+	store, err := NewKVIdempotencyStore(ctx, nc, "unit-tests", time.Minute)
+	assert.Nil(t, err)
+
+	got, err := store.Reserve(ctx, "a")
+	assert.Nil(t, got)
+	assert.Nil(t, err)
+	_, err = store.Reserve(ctx, "a")
+	assert.Equal(t, ErrInProgress, err)
+
+	assert.Nil(t, store.Save(ctx, "a", &StoredResponse{Header: nats.Header{"X-Key": {"1"}}, Data: []byte("a")}))
+	got, err = store.Reserve(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, &StoredResponse{Header: nats.Header{"X-Key": {"1"}}, Data: []byte("a")}, got)
+
+	_, _ = store.Reserve(ctx, "b")
+	assert.Nil(t, store.Release(ctx, "b"))
+	got, err = store.Reserve(ctx, "b")
+	assert.Nil(t, got)
+	assert.Nil(t, err)
+
+	// a second instance shares the bucket
+	other, err := NewKVIdempotencyStore(ctx, nc, "unit-tests", time.Minute)
+	assert.Nil(t, err)
+	got, err = other.Reserve(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", string(got.Data))
+}
@@ -7,7 +7,10 @@ import (
 // Middleware function signature
 type Middleware func(next nats.MsgHandler) nats.MsgHandler
 
-// UseMiddleware when interact with NATS
+// UseMiddleware when interact with NATS.
+// The middlewares are applied in order, so the last one passed runs first.
+//
+// Deprecated: use Chain, which runs the middlewares in the order they are given.
 func UseMiddleware(handler nats.MsgHandler, middleware ...Middleware) nats.MsgHandler {
 	for _, m := range middleware {
 		handler = m(handler)
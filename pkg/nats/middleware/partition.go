@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ventive/go-mono-template/pkg/logger"
+)
+
+const defaultPartitionQueueSize = 64
+
+// PartitionConfig godoc
+type PartitionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Partitions processed in parallel, 1 when not set
+	Partitions int `mapstructure:"partitions"`
+	// Header holding the partition key
+	Header string `mapstructure:"header"`
+	// Field of the JSON payload holding the partition key when the header is not set,
+	// as a dot separated path (e.g. data.account_id)
+	Field string `mapstructure:"field"`
+	// Token of the subject holding the partition key when neither the header nor the field is set,
+	// 1 for the first token, the same as the partition(n, token) subject mapping of the NATS server
+	Token int `mapstructure:"token"`
+	// QueueSize is the number of messages waiting per partition, 64 when not set.
+	// The subscription is blocked while the queue of a message is full.
+	QueueSize int `mapstructure:"queue_size"`
+}
+
+// PartitionOf returns the partition of key among partitions.
+// It is the partition computed by the partition(n, ...) subject mapping of the NATS server for the same key,
+// so the messages mapped to a subject partition land in the same local partition.
+func PartitionOf(key string, partitions int) int {
+	if partitions <= 0 {
+		return 0
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return int(h.Sum32() % uint32(partitions))
+}
+
+// PartitionSubject returns subject followed by the partition token of key,
+// the subject the partition(partitions, ...) mapping of the NATS server would map key to
+func PartitionSubject(subject, key string, partitions int) string {
+	return subject + "." + strconv.Itoa(PartitionOf(key, partitions))
+}
+
+// Partitioner processes the messages with the same key sequentially, in their delivery order,
+// while the messages of different partitions are processed in parallel.
+// The messages without key are spread over the partitions.
+type Partitioner struct {
+	source  string
+	cfg     PartitionConfig
+	handler nats.MsgHandler
+	queues  []chan *nats.Msg
+	next    atomic.Uint64
+	wg      sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewPartitioner starts a worker per partition processing its messages with handler,
+// source is the section of its logs
+func NewPartitioner(source string, cfg PartitionConfig, handler nats.MsgHandler) *Partitioner {
+	cfg.Partitions = max(cfg.Partitions, 1)
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultPartitionQueueSize
+	}
+
+	p := &Partitioner{source: source, cfg: cfg, handler: handler, queues: make([]chan *nats.Msg, cfg.Partitions)}
+	for i := range p.queues {
+		p.queues[i] = make(chan *nats.Msg, cfg.QueueSize)
+		p.wg.Add(1)
+		go p.work(p.queues[i])
+	}
+
+	return p
+}
+
+// Handle queues msg in its partition, it is the nats.MsgHandler of the subscription.
+// Once the partitioner is closed the messages are processed right away.
+func (p *Partitioner) Handle(msg *nats.Msg) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		p.handler(msg)
+		return
+	}
+
+	p.queues[p.partition(msg)] <- msg
+}
+
+// Close waits for the queued messages to be processed and stops the workers
+func (p *Partitioner) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, q := range p.queues {
+			close(q)
+		}
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+func (p *Partitioner) work(queue chan *nats.Msg) {
+	defer p.wg.Done()
+
+	for msg := range queue {
+		p.handler(msg)
+	}
+}
+
+func (p *Partitioner) partition(msg *nats.Msg) int {
+	key, err := p.key(msg)
+	if err != nil {
+		log := logger.New(p.source, "middleware.Partitioner")
+		log.AddMeta("subject", msg.Subject)
+		log.Warn("Could not read the partition key: " + err.Error())
+	}
+	if key == "" {
+		return int(p.next.Add(1) % uint64(len(p.queues)))
+	}
+
+	return PartitionOf(key, len(p.queues))
+}
+
+func (p *Partitioner) key(msg *nats.Msg) (string, error) {
+	if p.cfg.Header != "" {
+		if key := msg.Header.Get(p.cfg.Header); key != "" {
+			return key, nil
+		}
+	}
+
+	if p.cfg.Field != "" {
+		return fieldKey(msg.Data, p.cfg.Field)
+	}
+
+	if p.cfg.Token > 0 {
+		tokens := strings.Split(msg.Subject, ".")
+		if p.cfg.Token <= len(tokens) {
+			return tokens[p.cfg.Token-1], nil
+		}
+	}
+
+	return "", nil
+}
+
+// fieldKey reads the scalar at path in the JSON object data
+func fieldKey(data []byte, path string) (string, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", err
+	}
+
+	parent := "payload"
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("%s is not an object", parent)
+		}
+		value, parent = object[name], name
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string, float64, bool:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("%s is not a scalar", path)
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+func TestPartitioner(t *testing.T) {
+	t.Run("keepsOrderPerKey", func(t *testing.T) {
+		var mu sync.Mutex
+		got := map[string][]int{}
+		p := NewPartitioner("unit-tests", PartitionConfig{Partitions: 4, Header: "X-Key", QueueSize: 1}, func(msg *nats.Msg) {
+			// the first messages are the slowest ones, order is kept only by the partitions
+			var i int
+			_, _ = fmt.Sscan(string(msg.Data), &i)
+			time.Sleep(time.Duration(20-i) * 100 * time.Microsecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			key := msg.Header.Get("X-Key")
+			got[key] = append(got[key], i)
+		})
+
+		for i := range 20 {
+			msg := pkgnats.NewMsgWithHeaders("partition.unit-tests", map[string]string{"X-Key": fmt.Sprint(i % 3)})
+			msg.Data = []byte(fmt.Sprint(i))
+			p.Handle(msg)
+		}
+		p.Close()
+
+		assert.Equal(t, map[string][]int{
+			"0": {0, 3, 6, 9, 12, 15, 18},
+			"1": {1, 4, 7, 10, 13, 16, 19},
+			"2": {2, 5, 8, 11, 14, 17},
+		}, got)
+	})
+
+	t.Run("handlesAfterClose", func(t *testing.T) {
+		calls := 0
+		p := NewPartitioner("unit-tests", PartitionConfig{}, func(*nats.Msg) { calls++ })
+		p.Close()
+		p.Handle(nats.NewMsg("partition.unit-tests"))
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestPartitioner_key(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     PartitionConfig
+		subject string
+		header  map[string]string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{"header", PartitionConfig{Header: "X-Key", Field: "data.id"}, "a.b", map[string]string{"X-Key": "1"}, `{"data":{"id":2}}`, "1", false},
+		{"field when no header", PartitionConfig{Header: "X-Key", Field: "data.id"}, "a.b", nil, `{"data":{"id":2}}`, "2", false},
+		{"missing field", PartitionConfig{Field: "data.id"}, "a.b", nil, `{"data":{}}`, "", false},
+		{"field not in an object", PartitionConfig{Field: "data.id"}, "a.b", nil, `{"data":1}`, "", true},
+		{"field not a scalar", PartitionConfig{Field: "data"}, "a.b", nil, `{"data":{}}`, "", true},
+		{"subject token", PartitionConfig{Token: 2}, "a.b", nil, ``, "b", false},
+		{"missing subject token", PartitionConfig{Token: 3}, "a.b", nil, ``, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Partitioner{cfg: tt.cfg}
+			msg := pkgnats.NewMsgWithHeaders(tt.subject, tt.header)
+			msg.Data = []byte(tt.data)
+
+			got, err := p.key(msg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("key() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPartitionOf(t *testing.T) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	s := natsserver.RunServer(&opts)
+	defer s.Shutdown()
+	assert.Nil(t, s.GlobalAccount().AddMapping("partition.*", "partition.{{partition(7,1)}}.{{wildcard(1)}}"))
+
+	nc, err := nats.Connect(s.ClientURL())
+	assert.Nil(t, err)
+	defer nc.Close()
+
+	sub, err := nc.SubscribeSync("partition.*.*")
+	assert.Nil(t, err)
+
+	// the partitions match the ones of the server subject mapping
+	for _, key := range []string{"account-1", "account-2", "account-3", "42"} {
+		assert.Nil(t, nc.Publish("partition."+key, nil))
+		msg, err := sub.NextMsg(time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, msg.Subject, PartitionSubject("partition", key, 7)+"."+key)
+	}
+
+	assert.Equal(t, 0, PartitionOf("account-1", 0))
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/metrics"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+	"golang.org/x/time/rate"
+)
+
+// Rate limit keys and modes
+const (
+	RateLimitKeyGlobal  = "global"
+	RateLimitKeySubject = "subject"
+	RateLimitKeyHeader  = "header"
+
+	RateLimitModeReject = "reject"
+	RateLimitModeDelay  = "delay"
+)
+
+const rateLimitedMessage = "rate limited"
+
+// ErrRateLimitDelayNotPartitioned is returned for the delay mode of the subscriptions processed
+// without partitions, where a delayed message holds up the messages of every other key
+var ErrRateLimitDelayNotPartitioned = errors.New("rate limit delay mode requires partitioned processing")
+
+// RateLimitConfig godoc
+type RateLimitConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Key selects the token bucket of a message: global (default), subject or header
+	Key string `mapstructure:"key"`
+	// Header holding the bucket key when Key is header (e.g. X-Client-Id)
+	Header string `mapstructure:"header"`
+	// Rate of tokens per second added to every bucket
+	Rate float64 `mapstructure:"rate"`
+	// Burst is the size of every bucket
+	Burst int `mapstructure:"burst"`
+	// Mode is reject (default) to answer with the rate limited error or delay to wait for a token.
+	// delay requires the messages to be processed in partitions, see ErrRateLimitDelayNotPartitioned.
+	Mode string `mapstructure:"mode"`
+}
+
+type rateLimiter struct {
+	cfg     RateLimitConfig
+	publish func(*nats.Msg) error
+
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	lastScan time.Time
+
+	delayed *expvar.Map
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimit limits the rate of the messages with token buckets selected by cfg.Key.
+//
+// In reject mode the throttled messages are not processed and publish sends the standard
+// rate limited error to their reply subject. In delay mode the messages wait for a token,
+// unless their context is done first. They wait on the goroutine processing them, so the
+// subscriptions in delay mode must be processed by a Partitioner keyed like the buckets:
+// a delayed message then only holds up its partition rather than the whole subscription.
+// The throttled messages are counted per subject in the nats_rate_limited_total metric
+// and the delayed ones in nats_rate_delayed_total.
+func RateLimit(cfg RateLimitConfig, publish func(*nats.Msg) error) ContextMiddleware {
+	l := &rateLimiter{
+		cfg:      cfg,
+		publish:  publish,
+		buckets:  make(map[string]*bucket),
+		lastScan: time.Now(),
+		delayed:  metrics.CounterVec("nats_rate_delayed_total"),
+	}
+	limited := metrics.CounterVec("nats_rate_limited_total")
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *nats.Msg) {
+			if l.take(ctx, msg) {
+				next(ctx, msg)
+				return
+			}
+
+			limited.Add(msg.Subject, 1)
+			l.reject(ctx, msg)
+		}
+	}
+}
+
+// take reports whether msg got a token, waiting for it in delay mode
+func (l *rateLimiter) take(ctx context.Context, msg *nats.Msg) bool {
+	limiter := l.limiter(l.key(msg))
+	if l.cfg.Mode != RateLimitModeDelay {
+		return limiter.Allow()
+	}
+
+	reservation := limiter.Reserve()
+	if !reservation.OK() {
+		return false
+	}
+
+	delay := reservation.Delay()
+	if delay == 0 {
+		return true
+	}
+
+	l.delayed.Add(msg.Subject, 1)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		reservation.Cancel()
+		return false
+	}
+}
+
+func (l *rateLimiter) key(msg *nats.Msg) string {
+	switch l.cfg.Key {
+	case RateLimitKeySubject:
+		return msg.Subject
+	case RateLimitKeyHeader:
+		return msg.Header.Get(l.cfg.Header)
+	default:
+		return RateLimitKeyGlobal
+	}
+}
+
+func (l *rateLimiter) limiter(key string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.evict(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(l.cfg.Rate), l.cfg.Burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	return b.limiter
+}
+
+// evict drops the buckets unused for the time of a refill and back to full.
+// A full bucket behaves as a new one, so nothing is lost, while the ones left in debt
+// by the reservations of delay mode are kept.
+func (l *rateLimiter) evict(now time.Time) {
+	if l.cfg.Rate <= 0 {
+		return
+	}
+
+	refill := time.Duration(float64(l.cfg.Burst) / l.cfg.Rate * float64(time.Second))
+	if now.Sub(l.lastScan) < refill {
+		return
+	}
+	l.lastScan = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= refill && b.limiter.TokensAt(now) >= float64(l.cfg.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *rateLimiter) reject(ctx context.Context, msg *nats.Msg) {
+	log := logger.FromContext(ctx).WithAction("middleware.RateLimit")
+	log.Warn("Message rate limited")
+
+	if msg.Reply == "" || l.publish == nil {
+		return
+	}
+
+	reply := pkgnats.NewErrorMsg(msg.Reply, msg.Header, pkgnats.ErrorCodeRateLimited, rateLimitedMessage)
+	if err := Respond(ctx, l.publish, reply); err != nil {
+		log.Error("error when publishing rate limited reply", err)
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+	"golang.org/x/time/rate"
+)
+
+func newMsg(subject string, headers map[string]string) *nats.Msg {
+	msg := pkgnats.NewMsgWithHeaders(subject, headers)
+	msg.Reply = "reply"
+
+	return msg
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Run("rejectsOverBurst", func(t *testing.T) {
+		p := &publisher{}
+		calls := 0
+		handler := RateLimit(RateLimitConfig{Rate: 0.001, Burst: 2}, p.Publish)(func(_ context.Context, _ *nats.Msg) {
+			calls++
+		})
+
+		for range 3 {
+			handler(context.Background(), newMsg("unit-tests", nil))
+		}
+		assert.Equal(t, 2, calls)
+		assert.Len(t, p.msgs, 1)
+
+		var got pkgnats.Error
+		assert.Nil(t, json.Unmarshal(p.msgs[0].Data, &got))
+		assert.Equal(t, pkgnats.ErrorCodeRateLimited, got.Code)
+	})
+
+	t.Run("bucketsPerHeader", func(t *testing.T) {
+		calls := 0
+		handler := RateLimit(RateLimitConfig{Key: RateLimitKeyHeader, Header: "X-Client-Id", Rate: 0.001, Burst: 1}, nil)(
+			func(_ context.Context, _ *nats.Msg) {
+				calls++
+			})
+
+		handler(context.Background(), newMsg("unit-tests", map[string]string{"X-Client-Id": "a"}))
+		handler(context.Background(), newMsg("unit-tests", map[string]string{"X-Client-Id": "a"}))
+		handler(context.Background(), newMsg("unit-tests", map[string]string{"X-Client-Id": "b"}))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("delays", func(t *testing.T) {
+		calls := 0
+		handler := RateLimit(RateLimitConfig{Mode: RateLimitModeDelay, Rate: 50, Burst: 1}, nil)(
+			func(_ context.Context, _ *nats.Msg) {
+				calls++
+			})
+
+		start := time.Now()
+		handler(context.Background(), newMsg("unit-tests", nil))
+		handler(context.Background(), newMsg("unit-tests", nil))
+		assert.Equal(t, 2, calls)
+		assert.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+	})
+
+	t.Run("delayStopsWithContext", func(t *testing.T) {
+		p := &publisher{}
+		calls := 0
+		handler := RateLimit(RateLimitConfig{Mode: RateLimitModeDelay, Rate: 0.001, Burst: 1}, p.Publish)(
+			func(_ context.Context, _ *nats.Msg) {
+				calls++
+			})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		handler(ctx, newMsg("unit-tests", nil))
+		handler(ctx, newMsg("unit-tests", nil))
+		assert.Equal(t, 1, calls)
+		assert.Len(t, p.msgs, 1)
+	})
+
+	t.Run("evictsOnlyFullBuckets", func(t *testing.T) {
+		now := time.Now()
+		l := &rateLimiter{cfg: RateLimitConfig{Rate: 1, Burst: 1}, buckets: map[string]*bucket{}, lastScan: now}
+		for _, key := range []string{"idle", "indebted"} {
+			l.buckets[key] = &bucket{limiter: rate.NewLimiter(1, 1), lastSeen: now}
+		}
+		// delay mode reservations overdraw the bucket by 2 tokens
+		for range 3 {
+			l.buckets["indebted"].limiter.ReserveN(now, 1)
+		}
+
+		l.evict(now.Add(1500 * time.Millisecond))
+		assert.NotContains(t, l.buckets, "idle")
+		assert.Contains(t, l.buckets, "indebted")
+	})
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/metrics"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+// ErrPanic wraps the values recovered by Recover
+var ErrPanic = errors.New("panic")
+
+// internalErrorMessage is returned to the callers, the panic details are only logged
+const internalErrorMessage = "internal error"
+
+// RecoverConfig godoc
+type RecoverConfig struct {
+	// Source is the section used when logging the panics
+	Source string
+	// ErrorsSubject receives an error message for every recovered panic. Nothing is sent when empty.
+	ErrorsSubject string
+	// Publish sends the reply and the error messages. Nothing is sent when nil.
+	Publish func(msg *nats.Msg) error
+}
+
+// Recover catches the panics of the next handlers so they do not take down the process.
+//
+// The panic and its stack trace are logged, the caller gets the standard internal error
+// reply when the message has a reply subject and the panic is published to the errors subject.
+// The panics are counted per subject in the nats_handler_panics_total metric.
+func Recover(cfg RecoverConfig) Middleware {
+	panics := metrics.CounterVec("nats_handler_panics_total")
+
+	return func(next nats.MsgHandler) nats.MsgHandler {
+		return func(msg *nats.Msg) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+
+				panics.Add(msg.Subject, 1)
+				err := fmt.Errorf("%w: %v", ErrPanic, r)
+
+				log := logger.New(cfg.Source, "middleware.Recover")
+				log.ErrorWithExtra("Recovered from panic in message handler", map[string]interface{}{
+					"subject": msg.Subject,
+					"stack":   string(debug.Stack()),
+				}, err)
+
+				if cfg.Publish == nil {
+					return
+				}
+				if msg.Reply != "" {
+					reply := pkgnats.NewErrorMsg(msg.Reply, msg.Header, pkgnats.ErrorCodeInternal, internalErrorMessage)
+					if err := cfg.Publish(reply); err != nil {
+						log.Error("error when publishing panic reply", err)
+					}
+				}
+				if cfg.ErrorsSubject != "" {
+					errMsg := pkgnats.NewErrorMsg(cfg.ErrorsSubject, msg.Header, pkgnats.ErrorCodeInternal, err.Error())
+					if err := cfg.Publish(errMsg); err != nil {
+						log.Error("error when publishing panic error msg", err)
+					}
+				}
+			}()
+
+			next(msg)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/ventive/go-mono-template/pkg/metrics"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+type publisher struct {
+	msgs []*nats.Msg
+}
+
+func (p *publisher) Publish(msg *nats.Msg) error {
+	p.msgs = append(p.msgs, msg)
+	return nil
+}
+
+// counter returns the value of label in the CounterVec name, 0 when not set.
+// The counters are process-wide, the tests assert the deltas.
+func counter(name, label string) int64 {
+	if v, ok := metrics.CounterVec(name).Get(label).(*expvar.Int); ok {
+		return v.Value()
+	}
+
+	return 0
+}
+
+func TestRecover(t *testing.T) {
+	t.Run("recoversAndReplies", func(t *testing.T) {
+		p := &publisher{}
+		panics := counter("nats_handler_panics_total", "recover.unit-tests")
+		handler := UseMiddleware(func(_ *nats.Msg) {
+			panic("boom")
+		}, Recover(RecoverConfig{Source: "unit-tests", ErrorsSubject: "errors", Publish: p.Publish}))
+
+		msg := nats.NewMsg("recover.unit-tests")
+		msg.Reply = "reply"
+		msg.Header.Set("X-Request-Id", "1")
+		assert.NotPanics(t, func() { handler(msg) })
+
+		assert.Len(t, p.msgs, 2)
+		reply, errMsg := p.msgs[0], p.msgs[1]
+
+		var got pkgnats.Error
+		assert.Nil(t, json.Unmarshal(reply.Data, &got))
+		assert.Equal(t, "reply", reply.Subject)
+		assert.Equal(t, pkgnats.Error{Message: internalErrorMessage, Code: pkgnats.ErrorCodeInternal}, got)
+		assert.Equal(t, "1", reply.Header.Get("X-Request-Id"))
+
+		assert.Nil(t, json.Unmarshal(errMsg.Data, &got))
+		assert.Equal(t, "errors", errMsg.Subject)
+		assert.Equal(t, "panic: boom", got.Message)
+
+		assert.Equal(t, panics+1, counter("nats_handler_panics_total", "recover.unit-tests"))
+	})
+
+	t.Run("doesNothingWithoutPanic", func(t *testing.T) {
+		p := &publisher{}
+		called := false
+		handler := UseMiddleware(func(_ *nats.Msg) {
+			called = true
+		}, Recover(RecoverConfig{ErrorsSubject: "errors", Publish: p.Publish}))
+
+		handler(nats.NewMsg("unit-tests"))
+		assert.True(t, called)
+		assert.Empty(t, p.msgs)
+	})
+}
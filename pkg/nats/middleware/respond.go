@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Responder publishes the response of a message, to its reply subject or to an output subject
+type Responder func(msg *nats.Msg) error
+
+// ResponseInterceptor wraps the Responder of the handlers to observe or alter their responses
+type ResponseInterceptor func(next Responder) Responder
+
+type interceptorsKey struct{}
+
+// InterceptResponses returns a copy of ctx where the responses published with Respond go through i.
+// The interceptors added last, by the innermost middlewares, see the responses first.
+func InterceptResponses(ctx context.Context, i ResponseInterceptor) context.Context {
+	interceptors, _ := ctx.Value(interceptorsKey{}).([]ResponseInterceptor)
+
+	return context.WithValue(ctx, interceptorsKey{}, append(interceptors[:len(interceptors):len(interceptors)], i))
+}
+
+// Respond publishes the response msg of the message processed under ctx with publish,
+// through the interceptors of ctx
+func Respond(ctx context.Context, publish Responder, msg *nats.Msg) error {
+	interceptors, _ := ctx.Value(interceptorsKey{}).([]ResponseInterceptor)
+	for _, i := range interceptors {
+		publish = i(publish)
+	}
+
+	return publish(msg)
+}
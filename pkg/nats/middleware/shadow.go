@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/metrics"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+const (
+	defaultShadowTimeout       = 5 * time.Second
+	defaultShadowMaxConcurrent = 16
+)
+
+// ShadowConfig godoc
+type ShadowConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Handler name of the shadow handler
+	Handler string `mapstructure:"handler"`
+	// SampleRate is the share of the messages mirrored, between 0 and 1.
+	// Every message is mirrored when it is not in (0, 1).
+	SampleRate float64 `mapstructure:"sample_rate"`
+	// Timeout of the shadow handler, 5s when not set
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaxConcurrent shadow handlers, the messages are not mirrored above it. 16 when not set.
+	MaxConcurrent int `mapstructure:"max_concurrent"`
+	// Payload returns the part of the responses compared, e.g. the data of an envelope with unique ids.
+	// The whole payloads are compared when nil, or when it fails.
+	Payload func(msg *nats.Msg) ([]byte, error) `mapstructure:"-"`
+}
+
+type shadowKey struct{}
+
+// IsShadow reports whether the message processed under ctx is a shadow copy.
+// Handlers use it to skip the side effects not published with Respond.
+func IsShadow(ctx context.Context) bool {
+	shadow, _ := ctx.Value(shadowKey{}).(bool)
+
+	return shadow
+}
+
+// shadowResult is the response of a handler, nil when it did not respond
+type shadowResult struct {
+	data  []byte
+	error string
+}
+
+// Shadow mirrors the messages to the shadow handler, asynchronously and without affecting the callers.
+//
+// The shadow handler processes a copy of the message under a context detached from the one of the message,
+// its responses published with Respond are not sent but compared to the response of the next handler:
+// their JSON payloads, or the part returned by cfg.Payload, and error headers must be equal. The comparisons are counted per subject in the
+// nats_shadow_comparisons_total metric and the mismatches, logged with both payloads, in nats_shadow_mismatches_total.
+// The messages not mirrored because MaxConcurrent is reached are counted in nats_shadow_skipped_total.
+// The messages retried by DeadLetter are only mirrored on their first attempt.
+func Shadow(cfg ShadowConfig, shadow Handler) ContextMiddleware {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultShadowTimeout
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = defaultShadowMaxConcurrent
+	}
+	slots := make(chan struct{}, cfg.MaxConcurrent)
+
+	comparisons := metrics.CounterVec("nats_shadow_comparisons_total")
+	mismatches := metrics.CounterVec("nats_shadow_mismatches_total")
+	skipped := metrics.CounterVec("nats_shadow_skipped_total")
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *nats.Msg) {
+			// the retries of DeadLetter were mirrored with their first attempt
+			if isRetry(ctx) || (cfg.SampleRate > 0 && cfg.SampleRate < 1 && rand.Float64() >= cfg.SampleRate) {
+				next(ctx, msg)
+				return
+			}
+
+			select {
+			case slots <- struct{}{}:
+			default:
+				skipped.Add(msg.Subject, 1)
+				next(ctx, msg)
+				return
+			}
+
+			primary := make(chan *shadowResult, 1)
+			copied := &nats.Msg{Subject: msg.Subject, Reply: msg.Reply, Header: copyHeader(msg.Header), Data: slices.Clone(msg.Data)}
+			log := logger.FromContext(ctx).WithAction("middleware.Shadow")
+
+			go func() {
+				defer func() { <-slots }()
+
+				got := runShadow(ctx, cfg, shadow, copied)
+				want := <-primary
+
+				comparisons.Add(copied.Subject, 1)
+				if !got.equal(want) {
+					mismatches.Add(copied.Subject, 1)
+					log.ErrorWithExtra("Shadow response mismatch", map[string]interface{}{
+						"primary": want.String(),
+						"shadow":  got.String(),
+					})
+				}
+			}()
+
+			var result *shadowResult
+			defer func() { primary <- result }()
+
+			next(InterceptResponses(ctx, func(respond Responder) Responder {
+				return func(out *nats.Msg) error {
+					if result == nil {
+						result = newShadowResult(out, cfg.Payload)
+					}
+
+					return respond(out)
+				}
+			}), msg)
+		}
+	}
+}
+
+// runShadow returns the first response of shadow to msg, its responses are not published
+func runShadow(parent context.Context, cfg ShadowConfig, shadow Handler, msg *nats.Msg) (result *shadowResult) {
+	log := logger.FromContext(parent).WithAction("middleware.Shadow")
+	log.AddMeta("shadow", true)
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), cfg.Timeout)
+	defer cancel()
+	ctx = logger.NewContext(context.WithValue(ctx, shadowKey{}, true), log)
+	// the retry state of the message belongs to the primary handler, the shadow one is never retried
+	ctx = context.WithValue(ctx, retryKey{}, &retryState{})
+
+	defer func() {
+		if r := recover(); r != nil {
+			result = &shadowResult{error: fmt.Sprintf("%s: %v", ErrPanic, r)}
+		}
+	}()
+
+	shadow(InterceptResponses(ctx, func(Responder) Responder {
+		return func(out *nats.Msg) error {
+			if result == nil {
+				result = newShadowResult(out, cfg.Payload)
+			}
+
+			return nil
+		}
+	}), msg)
+
+	return result
+}
+
+func newShadowResult(msg *nats.Msg, payload func(msg *nats.Msg) ([]byte, error)) *shadowResult {
+	data := msg.Data
+	if payload != nil {
+		if p, err := payload(msg); err == nil {
+			data = p
+		}
+	}
+
+	return &shadowResult{data: slices.Clone(data), error: msg.Header.Get(pkgnats.ErrorHeader)}
+}
+
+// equal compares the JSON payloads of the results, or their bytes when they are not JSON
+func (r *shadowResult) equal(other *shadowResult) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	if r.error != other.error {
+		return false
+	}
+
+	var a, b interface{}
+	if json.Unmarshal(r.data, &a) != nil || json.Unmarshal(other.data, &b) != nil {
+		return bytes.Equal(r.data, other.data)
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func (r *shadowResult) String() string {
+	switch {
+	case r == nil:
+		return "no response"
+	case r.error != "":
+		return fmt.Sprintf("error %q: %s", r.error, r.data)
+	default:
+		return string(r.data)
+	}
+}
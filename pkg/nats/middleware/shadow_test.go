@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+func respondWith(data, errHeader string, p *publisher) Handler {
+	return func(ctx context.Context, msg *nats.Msg) {
+		out := nats.NewMsg(msg.Reply)
+		if errHeader != "" {
+			out.Header.Set(pkgnats.ErrorHeader, errHeader)
+		}
+		out.Data = []byte(data)
+		_ = Respond(ctx, p.Publish, out)
+	}
+}
+
+func TestShadow(t *testing.T) {
+	tests := []struct {
+		name         string
+		subject      string
+		primary      string
+		shadow       string
+		shadowErr    string
+		wantMismatch int64
+	}{
+		{"same response", "shadow.same", `{"a":1,"b":2}`, `{"b":2,"a":1}`, "", 0},
+		{"different response", "shadow.different", `3`, `{"result":3}`, "", 1},
+		{"shadow error", "shadow.error", `3`, `3`, "failed", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, shadowed := &publisher{}, &publisher{}
+			shadow := respondWith(tt.shadow, tt.shadowErr, shadowed)
+			handler := Shadow(ShadowConfig{}, shadow)(respondWith(tt.primary, "", p))
+			comparisons := counter("nats_shadow_comparisons_total", tt.subject)
+			mismatches := counter("nats_shadow_mismatches_total", tt.subject)
+
+			handler(context.Background(), newMsg(tt.subject, nil))
+
+			// the mismatches are counted after the comparisons
+			assert.Eventually(t, func() bool {
+				return counter("nats_shadow_comparisons_total", tt.subject) > comparisons &&
+					counter("nats_shadow_mismatches_total", tt.subject) == mismatches+tt.wantMismatch
+			}, time.Second, time.Millisecond)
+
+			assert.Len(t, p.msgs, 1)
+			assert.Equal(t, tt.primary, string(p.msgs[0].Data))
+			assert.Empty(t, shadowed.msgs)
+		})
+	}
+
+	t.Run("shadowGetsACopy", func(t *testing.T) {
+		p := &publisher{}
+		shadowed := make(chan *nats.Msg, 1)
+		var primaryShadow bool
+		handler := Shadow(ShadowConfig{}, func(ctx context.Context, msg *nats.Msg) {
+			assert.True(t, IsShadow(ctx))
+			shadowed <- msg
+		})(func(ctx context.Context, msg *nats.Msg) {
+			primaryShadow = IsShadow(ctx)
+			msg.Header.Set("X-Changed", "true")
+			respondWith("1", "", p)(ctx, msg)
+		})
+
+		msg := newMsg("shadow.copy", map[string]string{"X-Id": "1"})
+		handler(context.Background(), msg)
+		copied := <-shadowed
+
+		assert.False(t, primaryShadow)
+		assert.NotSame(t, msg, copied)
+		assert.Equal(t, "1", copied.Header.Get("X-Id"))
+		assert.Empty(t, copied.Header.Get("X-Changed"))
+	})
+
+	t.Run("payload", func(t *testing.T) {
+		p := &publisher{}
+		cfg := ShadowConfig{Payload: func(msg *nats.Msg) ([]byte, error) {
+			var envelope struct {
+				Data json.RawMessage `json:"data"`
+			}
+			err := json.Unmarshal(msg.Data, &envelope)
+			return envelope.Data, err
+		}}
+		shadow := respondWith(`{"id":"2","data":3}`, "", &publisher{})
+		handler := Shadow(cfg, shadow)(respondWith(`{"id":"1","data":3}`, "", p))
+		comparisons := counter("nats_shadow_comparisons_total", "shadow.payload")
+		mismatches := counter("nats_shadow_mismatches_total", "shadow.payload")
+
+		handler(context.Background(), newMsg("shadow.payload", nil))
+
+		assert.Eventually(t, func() bool {
+			return counter("nats_shadow_comparisons_total", "shadow.payload") > comparisons
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, mismatches, counter("nats_shadow_mismatches_total", "shadow.payload"))
+	})
+
+	t.Run("mirrorsFirstAttemptOnly", func(t *testing.T) {
+		p := &publisher{}
+		shadowed := make(chan bool, 2)
+		shadow := func(ctx context.Context, msg *nats.Msg) {
+			respondWith("1", "failed", &publisher{})(ctx, msg)
+			shadowed <- WillRetry(ctx)
+		}
+		primary := func(ctx context.Context, msg *nats.Msg) {
+			respondWith("1", "failed", p)(ctx, msg)
+			_ = WillRetry(ctx)
+		}
+		handler := DeadLetter("unit-tests", DeadLetterConfig{MaxAttempts: 2}, p.Publish)(
+			Shadow(ShadowConfig{}, shadow)(primary))
+
+		handler(context.Background(), newMsg("shadow.retried", nil))
+
+		assert.False(t, <-shadowed)
+		assert.Never(t, func() bool { return len(shadowed) > 0 }, 50*time.Millisecond, time.Millisecond)
+	})
+
+	t.Run("skippedAboveMaxConcurrent", func(t *testing.T) {
+		skipped := counter("nats_shadow_skipped_total", "shadow.skipped")
+		release := make(chan struct{})
+		handler := Shadow(ShadowConfig{MaxConcurrent: 1}, func(context.Context, *nats.Msg) {
+			<-release
+		})(func(context.Context, *nats.Msg) {})
+
+		handler(context.Background(), newMsg("shadow.skipped", nil))
+		handler(context.Background(), newMsg("shadow.skipped", nil))
+		close(release)
+
+		assert.Equal(t, skipped+1, counter("nats_shadow_skipped_total", "shadow.skipped"))
+	})
+}
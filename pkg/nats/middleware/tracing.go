@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ventive/go-mono-template/pkg/tracing"
+)
+
+// TraceContext starts a consumer span for every message, continuing the trace found in its headers.
+// The handler context carries the consumer span.
+//
+// The message headers are updated with the consumer span context, so the messages
+// published with the request headers are children of the processing span.
+func TraceContext(queueGroup string) ContextMiddleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *nats.Msg) {
+			ctx, span := tracing.StartConsumerSpan(ctx, msg, queueGroup)
+			defer span.End()
+
+			tracing.Inject(ctx, msg)
+
+			next(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+	"github.com/ventive/go-mono-template/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type fakeClient struct {
+	pkgnats.Client
+	published []*nats.Msg
+}
+
+func (f *fakeClient) PublishMsg(msg *nats.Msg) error {
+	f.published = append(f.published, msg)
+	return nil
+}
+
+func TestTraceContext(t *testing.T) {
+	shutdown, err := tracing.Init(context.Background(), "unit-tests", tracing.Config{
+		Enabled:     true,
+		Exporter:    tracing.ExporterMemory,
+		SampleRatio: 1,
+	})
+	assert.Nil(t, err)
+	defer func() {
+		_ = shutdown(context.Background())
+	}()
+	tracing.MemoryExporter().Reset()
+
+	// producer
+	ctx, root := tracing.Tracer().Start(context.Background(), "root")
+	msg := nats.NewMsg("trace.in")
+	msg.Data = []byte("{}")
+	tracing.Inject(ctx, msg)
+	root.End()
+
+	fake := &fakeClient{}
+	client := tracing.WrapClient(fake)
+	handler := TraceContext("group")(func(_ context.Context, msg *nats.Msg) {
+		out := nats.NewMsg("trace.out")
+		out.Header = msg.Header
+		_ = client.PublishMsg(out)
+	})
+	handler(context.Background(), msg)
+
+	spans := tracing.MemoryExporter().GetSpans()
+	assert.Len(t, spans, 3)
+	rootSpan, producer, consumer := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, trace.SpanKindConsumer, consumer.SpanKind)
+	assert.Equal(t, rootSpan.SpanContext.SpanID(), consumer.Parent.SpanID())
+	assert.Equal(t, trace.SpanKindProducer, producer.SpanKind)
+	assert.Equal(t, consumer.SpanContext.SpanID(), producer.Parent.SpanID())
+	assert.Equal(t, rootSpan.SpanContext.TraceID(), producer.SpanContext.TraceID())
+
+	// the published message carries the producer span context
+	got := trace.SpanContextFromContext(tracing.Extract(context.Background(), fake.published[0]))
+	assert.Equal(t, producer.SpanContext.SpanID(), got.SpanID())
+}
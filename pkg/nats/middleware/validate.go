@@ -0,0 +1,247 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/nats-io/nats.go"
+	"github.com/ventive/go-mono-template/pkg/decoder"
+	"github.com/ventive/go-mono-template/pkg/jsonschema"
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/metrics"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+// ErrNotDecoded is returned by Decoded when no Validate middleware decoded the message
+var ErrNotDecoded = errors.New("message not decoded")
+
+const validationFailedMessage = "validation failed"
+
+// Extractor returns the map of msg decoded by Validate
+type Extractor func(msg *nats.Msg) (map[string]interface{}, error)
+
+// JSONExtractor reads the JSON payload of the messages, or the object under its field key when field is set
+func JSONExtractor(field string) Extractor {
+	return func(msg *nats.Msg) (map[string]interface{}, error) {
+		var payload map[string]interface{}
+		if err := json.Unmarshal(msg.Data, &payload); err != nil {
+			return nil, err
+		}
+		if field == "" {
+			return payload, nil
+		}
+
+		data, ok := payload[field].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%q is not an object", field)
+		}
+
+		return data, nil
+	}
+}
+
+// ValidateConfig godoc
+type ValidateConfig struct {
+	// Extract returns the map to decode. When nil, the JSON payload, or its Field object, is decoded
+	// with decoder.DecodeBytes, without an intermediate map when Decode has the json TagName.
+	Extract Extractor
+	// Field of the JSON payload holding the object to decode, the whole payload when empty. Ignored with Extract.
+	Field string
+	// Data returns the JSON object to decode with decoder.DecodeBytes, e.g. from an envelope. Overrides Field.
+	Data func(msg *nats.Msg) ([]byte, error)
+	// Schema validates the JSON objects, or the maps of Extract, before they are decoded. Not validated when nil.
+	Schema *jsonschema.Schema
+	// Decode are the options of the decoder. Without Strict, the unknown keys of the Extract maps are logged
+	// at warn level.
+	Decode decoder.Options
+	// Subject receives the validation errors of the messages without reply subject. Nothing is sent when empty.
+	Subject string
+	// ErrorsSubject receives an error message for every invalid message, on its last attempt when retried
+	// by DeadLetter and not for the shadow copies. Nothing is sent when empty.
+	ErrorsSubject string
+	// Publish sends the validation errors. Nothing is sent when nil.
+	Publish func(msg *nats.Msg) error
+}
+
+type decodedKey[T any] struct{}
+
+// Decoded returns the value decoded by the Validate middleware of T
+func Decoded[T any](ctx context.Context) (T, error) {
+	value, ok := ctx.Value(decodedKey[T]{}).(T)
+	if !ok {
+		return value, ErrNotDecoded
+	}
+
+	return value, nil
+}
+
+// Validate decodes the messages into a T with pkg/decoder and passes it to the next handlers,
+// which read it with Decoded.
+//
+// The invalid messages are not processed: the standard validation error, listing every invalid
+// field with its failed tag and message in the cfg.Decode locale, is published with Respond to
+// their reply subject, or cfg.Subject, and to cfg.ErrorsSubject. They are counted per subject in
+// the nats_validation_failures_total metric.
+func Validate[T any](cfg ValidateConfig) ContextMiddleware {
+	decode := decodeBytes[T](cfg)
+	if cfg.Extract != nil {
+		decode = decodeMap[T](cfg)
+	}
+	failures := metrics.CounterVec("nats_validation_failures_total")
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, msg *nats.Msg) {
+			value, md, err := decode(msg)
+			if err != nil {
+				failures.Add(msg.Subject, 1)
+				rejectInvalid(ctx, msg, cfg, err)
+				return
+			}
+			if len(md.Unused) > 0 {
+				log := logger.FromContext(ctx).WithAction("middleware.Validate")
+				log.AddMeta("unknown_keys", md.Unused)
+				log.Warn("Unknown keys ignored")
+			}
+
+			next(context.WithValue(ctx, decodedKey[T]{}, value), msg)
+		}
+	}
+}
+
+type decodeFunc[T any] func(msg *nats.Msg) (T, decoder.Metadata, error)
+
+func decodeMap[T any](cfg ValidateConfig) decodeFunc[T] {
+	return func(msg *nats.Msg) (T, decoder.Metadata, error) {
+		var value T
+		data, err := cfg.Extract(msg)
+		if err == nil && cfg.Schema != nil {
+			err = cfg.Schema.Validate(data)
+		}
+		if err != nil {
+			return value, decoder.Metadata{}, err
+		}
+		md, err := decoder.DecodeWith(data, &value, cfg.Decode)
+
+		return value, md, err
+	}
+}
+
+// decodeBytes does not report the unknown keys, rejected in strict mode
+func decodeBytes[T any](cfg ValidateConfig) decodeFunc[T] {
+	return func(msg *nats.Msg) (T, decoder.Metadata, error) {
+		data, err := objectOf(cfg, msg)
+		if err == nil && cfg.Schema != nil {
+			err = cfg.Schema.ValidateJSON(data)
+		}
+		if err != nil {
+			var value T
+			return value, decoder.Metadata{}, err
+		}
+		value, err := decoder.DecodeBytes[T](data, cfg.Decode)
+
+		return value, decoder.Metadata{}, err
+	}
+}
+
+// objectOf returns the JSON object of msg read by cfg.Data, or under cfg.Field
+func objectOf(cfg ValidateConfig, msg *nats.Msg) ([]byte, error) {
+	if cfg.Data != nil {
+		return cfg.Data(msg)
+	}
+	if cfg.Field == "" {
+		return msg.Data, nil
+	}
+
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(msg.Data, &payload); err != nil {
+		return nil, err
+	}
+	data := payload[cfg.Field]
+	if !bytes.HasPrefix(data, []byte("{")) {
+		return nil, fmt.Errorf("%q is not an object", cfg.Field)
+	}
+
+	return data, nil
+}
+
+// FieldErrors lists the invalid fields reported by err
+func FieldErrors(err error) []pkgnats.FieldError {
+	var validationErrors decoder.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make([]pkgnats.FieldError, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			fields = append(fields, pkgnats.FieldError{Field: fe.Field, Tag: fe.Tag, Param: fe.Param, Message: fe.Message})
+		}
+
+		return fields
+	}
+
+	var schemaErrors jsonschema.ValidationErrors
+	if errors.As(err, &schemaErrors) {
+		fields := make([]pkgnats.FieldError, 0, len(schemaErrors))
+		for _, e := range schemaErrors {
+			fields = append(fields, pkgnats.FieldError{Field: e.Path, Tag: e.Keyword, Message: e.Message})
+		}
+
+		return fields
+	}
+
+	var unusedKeys *decoder.UnusedKeysError
+	if errors.As(err, &unusedKeys) {
+		fields := make([]pkgnats.FieldError, 0, len(unusedKeys.Keys))
+		for _, key := range unusedKeys.Keys {
+			fields = append(fields, pkgnats.FieldError{Field: key, Tag: "unknown", Message: "unknown key " + key})
+		}
+
+		return fields
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		return []pkgnats.FieldError{{Field: typeError.Field, Tag: "decode", Message: err.Error()}}
+	}
+
+	var decodeError *mapstructure.Error
+	if errors.As(err, &decodeError) {
+		fields := make([]pkgnats.FieldError, 0, len(decodeError.Errors))
+		for _, e := range decodeError.Errors {
+			fields = append(fields, pkgnats.FieldError{Tag: "decode", Message: e})
+		}
+
+		return fields
+	}
+
+	return []pkgnats.FieldError{{Tag: "decode", Message: err.Error()}}
+}
+
+func rejectInvalid(ctx context.Context, msg *nats.Msg, cfg ValidateConfig, err error) {
+	log := logger.FromContext(ctx).WithAction("middleware.Validate")
+	log.Error("Invalid message", err)
+
+	if cfg.Publish == nil {
+		return
+	}
+
+	fields := FieldErrors(err)
+	subject := cfg.Subject
+	if msg.Reply != "" {
+		subject = msg.Reply
+	}
+	if subject != "" {
+		reply := pkgnats.NewValidationErrorMsg(subject, msg.Header, validationFailedMessage, fields)
+		if err := Respond(ctx, cfg.Publish, reply); err != nil {
+			log.Error("error when publishing validation error reply", err)
+		}
+	}
+	// like the handlers, only the last attempt of the messages retried by DeadLetter is reported
+	if cfg.ErrorsSubject != "" && !WillRetry(ctx) && !IsShadow(ctx) {
+		errMsg := pkgnats.NewValidationErrorMsg(cfg.ErrorsSubject, msg.Header, validationFailedMessage, fields)
+		if err := cfg.Publish(errMsg); err != nil {
+			log.Error("error when publishing validation error", err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/ventive/go-mono-template/pkg/decoder"
+	"github.com/ventive/go-mono-template/pkg/jsonschema"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+type validateEvent struct {
+	A float64 `mapstructure:"a" json:"a" validate:"required"`
+	B float64 `mapstructure:"b" json:"b" validate:"gte=0"`
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		decode     decoder.Options
+		want       *validateEvent
+		wantFields []pkgnats.FieldError
+	}{
+		{"valid", `{"data":{"a":1,"b":2}}`, decoder.Options{}, &validateEvent{A: 1, B: 2}, nil},
+		{"unknown keys ignored", `{"data":{"a":1,"c":2}}`, decoder.Options{}, &validateEvent{A: 1}, nil},
+		{"unknown keys rejected", `{"data":{"a":1,"d":2,"c":2}}`, decoder.Options{Strict: true}, nil, []pkgnats.FieldError{
+			{Field: "c", Tag: "unknown", Message: "unknown key c"},
+			{Field: "d", Tag: "unknown", Message: "unknown key d"},
+		}},
+		{"weakly typed", `{"data":{"a":"1"}}`, decoder.Options{WeaklyTyped: true}, &validateEvent{A: 1}, nil},
+		{"invalid fields", `{"data":{"b":-1}}`, decoder.Options{}, nil, []pkgnats.FieldError{
+			{Field: "a", Tag: "required", Message: "a is a required field"},
+			{Field: "b", Tag: "gte", Param: "0", Message: "b must be 0 or greater"},
+		}},
+		{"wrong type", `{"data":{"a":"1"}}`, decoder.Options{}, nil, []pkgnats.FieldError{
+			{Tag: "decode", Message: "'a' expected type 'float64', got unconvertible type 'string', value: '1'"},
+		}},
+		{"missing data", `{}`, decoder.Options{}, nil, []pkgnats.FieldError{{Tag: "decode", Message: `"data" is not an object`}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &publisher{}
+			var got *validateEvent
+			handler := Validate[validateEvent](ValidateConfig{
+				Extract:       JSONExtractor("data"),
+				Decode:        tt.decode,
+				ErrorsSubject: "errors",
+				Publish:       p.Publish,
+			})(func(ctx context.Context, _ *nats.Msg) {
+				event, err := Decoded[validateEvent](ctx)
+				assert.Nil(t, err)
+				got = &event
+			})
+
+			msg := newMsg("validate.unit-tests", nil)
+			msg.Data = []byte(tt.data)
+			handler(context.Background(), msg)
+
+			assert.Equal(t, tt.want, got)
+			if tt.wantFields == nil {
+				assert.Empty(t, p.msgs)
+				return
+			}
+
+			assert.Len(t, p.msgs, 2)
+			assert.Equal(t, "reply", p.msgs[0].Subject)
+			assert.Equal(t, "errors", p.msgs[1].Subject)
+			var reply pkgnats.Error
+			assert.Nil(t, json.Unmarshal(p.msgs[0].Data, &reply))
+			assert.Equal(t, pkgnats.ErrorCodeValidation, reply.Code)
+			assert.Equal(t, tt.wantFields, reply.Fields)
+		})
+	}
+
+	t.Run("reportsLastAttemptOnly", func(t *testing.T) {
+		p := &publisher{}
+		attempts := 0
+		handler := Chain{}.
+			Append(NameDeadLetter, DeadLetter("unit-tests", DeadLetterConfig{Subject: "dead-letters", MaxAttempts: 3}, p.Publish)).
+			Append("validate", func(next Handler) Handler {
+				validate := Validate[validateEvent](ValidateConfig{ErrorsSubject: "errors", Publish: p.Publish})(next)
+				return func(ctx context.Context, msg *nats.Msg) {
+					attempts++
+					validate(ctx, msg)
+				}
+			}).
+			Then(func(context.Context, *nats.Msg) {})
+
+		msg := newMsg("validate.unit-tests", nil)
+		msg.Data = []byte(`{"b":-1}`)
+		handler(context.Background(), msg)
+
+		assert.Equal(t, 3, attempts)
+		var subjects []string
+		for _, m := range p.msgs {
+			subjects = append(subjects, m.Subject)
+		}
+		assert.Equal(t, []string{"reply", "errors", "dead-letters"}, subjects)
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			data       string
+			decode     decoder.Options
+			want       *validateEvent
+			wantFields []pkgnats.FieldError
+		}{
+			{"valid", `{"data":{"a":1,"b":2}}`, decoder.Options{TagName: "json"}, &validateEvent{A: 1, B: 2}, nil},
+			{"unknown keys rejected", `{"data":{"a":1,"c":2}}`, decoder.Options{Strict: true, TagName: "json"}, nil, []pkgnats.FieldError{
+				{Field: "c", Tag: "unknown", Message: "unknown key c"},
+			}},
+			{"invalid fields", `{"data":{"b":-1}}`, decoder.Options{TagName: "json"}, nil, []pkgnats.FieldError{
+				{Field: "a", Tag: "required", Message: "a is a required field"},
+				{Field: "b", Tag: "gte", Param: "0", Message: "b must be 0 or greater"},
+			}},
+			{"wrong type", `{"data":{"a":"1"}}`, decoder.Options{TagName: "json"}, nil, []pkgnats.FieldError{
+				{Field: "a", Tag: "decode", Message: "json: cannot unmarshal string into Go struct field validateEvent.a of type float64"},
+			}},
+			{"missing data", `{}`, decoder.Options{TagName: "json"}, nil, []pkgnats.FieldError{{Tag: "decode", Message: `"data" is not an object`}}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				p := &publisher{}
+				var got *validateEvent
+				handler := Validate[validateEvent](ValidateConfig{
+					Field:   "data",
+					Decode:  tt.decode,
+					Publish: p.Publish,
+				})(func(ctx context.Context, _ *nats.Msg) {
+					event, err := Decoded[validateEvent](ctx)
+					assert.Nil(t, err)
+					got = &event
+				})
+
+				msg := newMsg("validate.bytes.unit-tests", nil)
+				msg.Data = []byte(tt.data)
+				handler(context.Background(), msg)
+
+				assert.Equal(t, tt.want, got)
+				if tt.wantFields == nil {
+					assert.Empty(t, p.msgs)
+					return
+				}
+
+				assert.Len(t, p.msgs, 1)
+				var reply pkgnats.Error
+				assert.Nil(t, json.Unmarshal(p.msgs[0].Data, &reply))
+				assert.Equal(t, tt.wantFields, reply.Fields)
+			})
+		}
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		schema, err := jsonschema.Generate(validateEvent{})
+		assert.Nil(t, err)
+
+		for _, cfg := range []ValidateConfig{{Field: "data"}, {Extract: JSONExtractor("data")}} {
+			p := &publisher{}
+			cfg.Schema = schema
+			cfg.Publish = p.Publish
+			handler := Validate[validateEvent](cfg)(func(context.Context, *nats.Msg) {
+				t.Error("invalid message processed")
+			})
+
+			msg := newMsg("validate.schema.unit-tests", nil)
+			msg.Data = []byte(`{"data":{"b":"1"}}`)
+			handler(context.Background(), msg)
+
+			assert.Len(t, p.msgs, 1)
+			var reply pkgnats.Error
+			assert.Nil(t, json.Unmarshal(p.msgs[0].Data, &reply))
+			assert.Equal(t, []pkgnats.FieldError{
+				{Field: "a", Tag: "required", Message: "a is required"},
+				{Field: "b", Tag: "type", Message: "b must be of type number"},
+			}, reply.Fields)
+		}
+	})
+
+	t.Run("data", func(t *testing.T) {
+		var got validateEvent
+		handler := Validate[validateEvent](ValidateConfig{
+			Data: func(msg *nats.Msg) ([]byte, error) {
+				return msg.Data[len("body:"):], nil
+			},
+		})(func(ctx context.Context, _ *nats.Msg) {
+			got, _ = Decoded[validateEvent](ctx)
+		})
+
+		msg := newMsg("validate.data.unit-tests", nil)
+		msg.Data = []byte(`body:{"a":1,"b":2}`)
+		handler(context.Background(), msg)
+
+		assert.Equal(t, validateEvent{A: 1, B: 2}, got)
+	})
+
+	t.Run("notDecoded", func(t *testing.T) {
+		_, err := Decoded[validateEvent](context.Background())
+		assert.Equal(t, ErrNotDecoded, err)
+	})
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/metrics"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+const (
+	// APIVersionHeader selects the version of the handler processing a message,
+	// it is set on the responses to the version that processed it
+	APIVersionHeader = "Api-Version"
+	// DeprecationHeader is set on the responses of the deprecated versions
+	DeprecationHeader = "Deprecation"
+	// SunsetHeader is set on the responses of the deprecated versions with a sunset date
+	SunsetHeader = "Sunset"
+)
+
+var (
+	// ErrUnknownHandler is returned when a version route, or the shadow config, names a missing handler
+	ErrUnknownHandler = errors.New("unknown handler")
+	// ErrUnknownVersion is returned when the default version has no route
+	ErrUnknownVersion = errors.New("unknown version")
+)
+
+// VersionConfig godoc
+type VersionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Default version of the messages without version
+	Default string `mapstructure:"default"`
+	// SubjectSuffix reads the version from the last subject token (e.g. inbox.v2) when the header is not set.
+	// The subscription subject must then match the suffixed subjects.
+	SubjectSuffix bool `mapstructure:"subject_suffix"`
+	// Routes of the versions served
+	Routes []VersionRoute `mapstructure:"routes"`
+}
+
+// VersionRoute godoc
+type VersionRoute struct {
+	Version string `mapstructure:"version"`
+	// Handler name, in the handlers given to VersionRouter
+	Handler string `mapstructure:"handler"`
+	// Deprecated versions are still served with a warning
+	Deprecated bool `mapstructure:"deprecated"`
+	// Sunset is the date the deprecated version will be removed, as an HTTP date
+	Sunset string `mapstructure:"sunset"`
+}
+
+type route struct {
+	VersionRoute
+	handler Handler
+}
+
+type versionKey struct{}
+
+// APIVersion returns the version of the message processed under ctx
+func APIVersion(ctx context.Context) string {
+	version, _ := ctx.Value(versionKey{}).(string)
+
+	return version
+}
+
+// VersionRouter returns the handler dispatching the messages to the handler of their version, by name in handlers.
+//
+// The version is read from the APIVersionHeader, or the subject suffix when enabled, and defaults to cfg.Default.
+// The messages of an unknown version are not processed, publish sends the standard unsupported version error
+// to their reply subject. The responses published with Respond get the APIVersionHeader and, for the deprecated
+// versions, the DeprecationHeader and the SunsetHeader. The messages of the deprecated versions are logged
+// and counted per version in the nats_deprecated_version_total metric.
+func VersionRouter(cfg VersionConfig, handlers map[string]Handler, publish func(*nats.Msg) error) (Handler, error) {
+	routes := make(map[string]route, len(cfg.Routes))
+	for _, r := range cfg.Routes {
+		h, ok := handlers[r.Handler]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q for version %s", ErrUnknownHandler, r.Handler, r.Version)
+		}
+		routes[r.Version] = route{VersionRoute: r, handler: h}
+	}
+	if _, ok := routes[cfg.Default]; !ok {
+		return nil, fmt.Errorf("%w: default %q", ErrUnknownVersion, cfg.Default)
+	}
+
+	deprecated := metrics.CounterVec("nats_deprecated_version_total")
+
+	return func(ctx context.Context, msg *nats.Msg) {
+		version := messageVersion(cfg, routes, msg)
+		log := logger.FromContext(ctx).WithAction("middleware.VersionRouter")
+		log.AddMeta("api_version", version)
+
+		r, ok := routes[version]
+		if !ok {
+			log.Warn("Unsupported API version")
+			rejectVersion(ctx, log, msg, version, publish)
+			return
+		}
+
+		if r.Deprecated {
+			deprecated.Add(version, 1)
+			log.Warn("Deprecated API version " + version)
+		}
+
+		ctx = InterceptResponses(context.WithValue(ctx, versionKey{}, version), func(respond Responder) Responder {
+			return func(out *nats.Msg) error {
+				if out.Header == nil {
+					out.Header = nats.Header{}
+				}
+				out.Header.Set(APIVersionHeader, version)
+				if r.Deprecated {
+					out.Header.Set(DeprecationHeader, "true")
+					if r.Sunset != "" {
+						out.Header.Set(SunsetHeader, r.Sunset)
+					}
+				}
+
+				return respond(out)
+			}
+		})
+		r.handler(ctx, msg)
+	}, nil
+}
+
+func messageVersion(cfg VersionConfig, routes map[string]route, msg *nats.Msg) string {
+	if version := msg.Header.Get(APIVersionHeader); version != "" {
+		return version
+	}
+
+	if cfg.SubjectSuffix {
+		suffix := msg.Subject[strings.LastIndex(msg.Subject, ".")+1:]
+		if _, ok := routes[suffix]; ok {
+			return suffix
+		}
+	}
+
+	return cfg.Default
+}
+
+func rejectVersion(ctx context.Context, log *logger.Logger, msg *nats.Msg, version string, publish func(*nats.Msg) error) {
+	if msg.Reply == "" || publish == nil {
+		return
+	}
+
+	reply := pkgnats.NewErrorMsg(msg.Reply, msg.Header, pkgnats.ErrorCodeUnsupportedVersion, "unsupported api version "+version)
+	if err := Respond(ctx, publish, reply); err != nil {
+		log.Error("error when publishing unsupported version reply", err)
+	}
+}
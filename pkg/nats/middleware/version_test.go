@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+func TestVersionRouter(t *testing.T) {
+	cfg := VersionConfig{
+		Default:       "v1",
+		SubjectSuffix: true,
+		Routes: []VersionRoute{
+			{Version: "v1", Handler: "v1", Deprecated: true, Sunset: "Fri, 01 Jan 2027 00:00:00 GMT"},
+			{Version: "v2", Handler: "v2"},
+		},
+	}
+
+	var p *publisher
+	handlers := map[string]Handler{}
+	for _, name := range []string{"v1", "v2"} {
+		handlers[name] = func(ctx context.Context, msg *nats.Msg) {
+			out := nats.NewMsg(msg.Reply)
+			out.Data = []byte(name + "/" + APIVersion(ctx))
+			_ = Respond(ctx, p.Publish, out)
+		}
+	}
+
+	router, err := VersionRouter(cfg, handlers, func(msg *nats.Msg) error { return p.Publish(msg) })
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name           string
+		subject        string
+		header         map[string]string
+		want           string
+		wantDeprecated bool
+		wantCode       string
+	}{
+		{"default", "version.inbox", nil, "v1/v1", true, ""},
+		{"header", "version.inbox", map[string]string{APIVersionHeader: "v2"}, "v2/v2", false, ""},
+		{"subject suffix", "version.inbox.v2", nil, "v2/v2", false, ""},
+		{"header over suffix", "version.inbox.v2", map[string]string{APIVersionHeader: "v1"}, "v1/v1", true, ""},
+		{"unknown suffix", "version.inbox.v3", nil, "v1/v1", true, ""},
+		{"unsupported", "version.inbox", map[string]string{APIVersionHeader: "v3"}, "", false, pkgnats.ErrorCodeUnsupportedVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p = &publisher{}
+			router(context.Background(), newMsg(tt.subject, tt.header))
+
+			assert.Len(t, p.msgs, 1)
+			reply := p.msgs[0]
+			if tt.wantCode != "" {
+				var got pkgnats.Error
+				assert.Nil(t, json.Unmarshal(reply.Data, &got))
+				assert.Equal(t, tt.wantCode, got.Code)
+				return
+			}
+
+			assert.Equal(t, tt.want, string(reply.Data))
+			assert.Equal(t, tt.want[:2], reply.Header.Get(APIVersionHeader))
+			if tt.wantDeprecated {
+				assert.Equal(t, "true", reply.Header.Get(DeprecationHeader))
+				assert.Equal(t, cfg.Routes[0].Sunset, reply.Header.Get(SunsetHeader))
+			} else {
+				assert.Empty(t, reply.Header.Get(DeprecationHeader))
+			}
+		})
+	}
+
+	t.Run("unknownHandler", func(t *testing.T) {
+		_, err := VersionRouter(VersionConfig{Default: "v1", Routes: []VersionRoute{{Version: "v1", Handler: "v3"}}}, handlers, nil)
+		assert.True(t, errors.Is(err, ErrUnknownHandler))
+	})
+
+	t.Run("unknownDefault", func(t *testing.T) {
+		_, err := VersionRouter(VersionConfig{Default: "v3"}, handlers, nil)
+		assert.True(t, errors.Is(err, ErrUnknownVersion))
+	})
+}
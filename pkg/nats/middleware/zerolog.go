@@ -1,32 +1,161 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
+	"math/rand/v2"
+	"net/textproto"
+	"slices"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/ventive/go-mono-template/pkg/logger"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
 )
 
-var (
-	// LogSource godoc
-	LogSource = "default-log-source"
-	// LogEnv godoc
-	LogEnv = ""
+// Outcomes logged when the processing of a message completes
+const (
+	LogOutcomeOK         = "ok"
+	LogOutcomeError      = "error"
+	LogOutcomeNoResponse = "no_response"
+	LogOutcomePanic      = "panic"
 )
 
-// Log reads the headers from NATS message and log them using zerolog pkg
-func Log(next nats.MsgHandler) nats.MsgHandler {
-	return func(msg *nats.Msg) {
-		zerologHeaderParams := zerolog.Dict()
-		for k, v := range msg.Header {
-			zerologHeaderParams = zerologHeaderParams.Interface(string(k), v)
+const redacted = "[REDACTED]"
+
+// defaultDenyHeaders are always redacted
+var defaultDenyHeaders = []string{AuthorizationHeader, "Cookie", "Nats-Auth-Token"}
+
+// LogConfig godoc
+type LogConfig struct {
+	// Level of the entries, debug by default. Failed messages are logged at warn level at least.
+	Level string `mapstructure:"level"`
+	// AllowHeaders restricts the logged headers to these ones when set
+	AllowHeaders []string `mapstructure:"allow_headers"`
+	// DenyHeaders have their values redacted, in addition to Authorization, Cookie and Nats-Auth-Token
+	DenyHeaders []string `mapstructure:"deny_headers"`
+	// Payload logs the message data, truncated to MaxPayload bytes when MaxPayload is positive
+	Payload    bool `mapstructure:"payload"`
+	MaxPayload int  `mapstructure:"max_payload"`
+	// SampleRate is the share of the messages logged, between 0 and 1.
+	// Every message is logged when it is not in (0, 1), failed messages are always logged.
+	SampleRate float64 `mapstructure:"sample_rate"`
+}
+
+type messageLogger struct {
+	source string
+	env    string
+	cfg    LogConfig
+	level  zerolog.Level
+	allow  []string
+	deny   []string
+}
+
+// Log logs the headers of the messages, their payload when enabled, then the duration and the outcome
+// of their processing. The outcome is read from the response published with Respond: error when it
+// carries the ErrorHeader, ok otherwise, no_response when nothing was published and panic.
+// Every entry has the source and the env of the service.
+func Log(source, env string, cfg LogConfig) (ContextMiddleware, error) {
+	l := &messageLogger{source: source, env: env, cfg: cfg, level: zerolog.DebugLevel}
+	if cfg.Level != "" {
+		level, err := zerolog.ParseLevel(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		l.level = level
+	}
+
+	for _, h := range cfg.AllowHeaders {
+		l.allow = append(l.allow, textproto.CanonicalMIMEHeaderKey(h))
+	}
+	for _, h := range append(slices.Clone(defaultDenyHeaders), cfg.DenyHeaders...) {
+		l.deny = append(l.deny, textproto.CanonicalMIMEHeaderKey(h))
+	}
+
+	return l.middleware, nil
+}
+
+func (l *messageLogger) middleware(next Handler) Handler {
+	return func(ctx context.Context, msg *nats.Msg) {
+		log := logger.FromContext(ctx).WithAction("middleware.Log")
+		sampled := l.cfg.SampleRate <= 0 || l.cfg.SampleRate >= 1 || rand.Float64() < l.cfg.SampleRate
+
+		if sampled {
+			event := l.event(log, l.level).Dict("headers", l.headers(msg.Header))
+			if l.cfg.Payload {
+				event = event.Str("payload", l.payload(msg.Data))
+			}
+			event.Msg(fmt.Sprintf("Processing message from=%s", msg.Subject))
 		}
 
-		log.Debug().Str("name", LogSource).
-			Str("env", LogEnv).
-			Dict("headers", zerologHeaderParams).Msg(fmt.Sprintf("Processing message from=%s", msg.Subject))
+		start := time.Now()
+		outcome, errMessage := LogOutcomeNoResponse, ""
+		defer func() {
+			r := recover()
+			if r != nil {
+				outcome, errMessage = LogOutcomePanic, fmt.Sprint(r)
+			}
+
+			level := l.level
+			if outcome == LogOutcomeError || outcome == LogOutcomePanic {
+				level = max(level, zerolog.WarnLevel)
+			} else if !sampled {
+				level = zerolog.Disabled
+			}
 
-		next(msg)
+			event := l.event(log, level).Dur("duration", time.Since(start)).Str("outcome", outcome)
+			if errMessage != "" {
+				event = event.Str("error", errMessage)
+			}
+			event.Msg(fmt.Sprintf("Processed message from=%s", msg.Subject))
+
+			if r != nil {
+				panic(r)
+			}
+		}()
+
+		next(InterceptResponses(ctx, func(respond Responder) Responder {
+			return func(out *nats.Msg) error {
+				if outcome == LogOutcomeNoResponse {
+					outcome = LogOutcomeOK
+					if errMessage = out.Header.Get(pkgnats.ErrorHeader); errMessage != "" {
+						outcome = LogOutcomeError
+					}
+				}
+
+				return respond(out)
+			}
+		}), msg)
 	}
 }
+
+func (l *messageLogger) event(log *logger.Logger, level zerolog.Level) *zerolog.Event {
+	return log.Zerolog(zlog.WithLevel(level)).Str("name", l.source).Str("env", l.env)
+}
+
+func (l *messageLogger) headers(header nats.Header) *zerolog.Event {
+	dict := zerolog.Dict()
+	for k, v := range header {
+		key := textproto.CanonicalMIMEHeaderKey(k)
+		if len(l.allow) > 0 && !slices.Contains(l.allow, key) {
+			continue
+		}
+		if slices.Contains(l.deny, key) {
+			dict = dict.Str(k, redacted)
+			continue
+		}
+		dict = dict.Interface(k, v)
+	}
+
+	return dict
+}
+
+func (l *messageLogger) payload(data []byte) string {
+	if l.cfg.MaxPayload > 0 && len(data) > l.cfg.MaxPayload {
+		return string(data[:l.cfg.MaxPayload]) + "..."
+	}
+
+	return string(data)
+}
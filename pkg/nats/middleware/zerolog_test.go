@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	pkgnats "github.com/ventive/go-mono-template/pkg/nats"
+)
+
+// captureLogs redirects the global logger to the returned buffer for the duration of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous, level := zlog.Logger, zerolog.GlobalLevel()
+	zlog.Logger = zerolog.New(&buf)
+	zerolog.SetGlobalLevel(zerolog.TraceLevel)
+	t.Cleanup(func() {
+		zlog.Logger = previous
+		zerolog.SetGlobalLevel(level)
+	})
+
+	return &buf
+}
+
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var got []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, entry)
+	}
+
+	return got
+}
+
+func TestLog(t *testing.T) {
+	respond := func(header string) Handler {
+		return func(ctx context.Context, msg *nats.Msg) {
+			out := nats.NewMsg("out")
+			if header != "" {
+				out.Header.Set(pkgnats.ErrorHeader, header)
+			}
+			_ = Respond(ctx, func(*nats.Msg) error { return nil }, out)
+		}
+	}
+
+	t.Run("logsHeadersPayloadAndOutcome", func(t *testing.T) {
+		buf := captureLogs(t)
+		m, err := Log("unit-tests", "test", LogConfig{
+			Level:       "info",
+			DenyHeaders: []string{"x-secret"},
+			Payload:     true,
+			MaxPayload:  4,
+		})
+		assert.Nil(t, err)
+
+		msg := newMsg("log.unit-tests", map[string]string{"X-Secret": "1", "Authorization": "Bearer 2", "X-Id": "3"})
+		msg.Data = []byte("123456")
+		m(respond(""))(context.Background(), msg)
+
+		got := entries(t, buf)
+		assert.Len(t, got, 2)
+		assert.Equal(t, "info", got[0]["level"])
+		assert.Equal(t, "unit-tests", got[0]["name"])
+		assert.Equal(t, "test", got[0]["env"])
+		assert.Equal(t, "1234...", got[0]["payload"])
+		assert.Equal(t, map[string]any{"X-Secret": redacted, "Authorization": redacted, "X-Id": []any{"3"}}, got[0]["headers"])
+		assert.Equal(t, LogOutcomeOK, got[1]["outcome"])
+		assert.Contains(t, got[1], "duration")
+	})
+
+	t.Run("allowHeaders", func(t *testing.T) {
+		buf := captureLogs(t)
+		m, _ := Log("unit-tests", "test", LogConfig{AllowHeaders: []string{"x-id"}})
+
+		m(respond(""))(context.Background(), newMsg("log.unit-tests", map[string]string{"X-Other": "1", "X-Id": "2"}))
+
+		got := entries(t, buf)
+		assert.Equal(t, "debug", got[0]["level"])
+		assert.Equal(t, map[string]any{"X-Id": []any{"2"}}, got[0]["headers"])
+	})
+
+	t.Run("failuresAreLoggedWhenNotSampled", func(t *testing.T) {
+		buf := captureLogs(t)
+		m, _ := Log("unit-tests", "test", LogConfig{SampleRate: 0.000001})
+
+		m(respond(""))(context.Background(), newMsg("log.unit-tests", nil))
+		m(respond("failed"))(context.Background(), newMsg("log.unit-tests", nil))
+
+		got := entries(t, buf)
+		assert.Len(t, got, 1)
+		assert.Equal(t, "warn", got[0]["level"])
+		assert.Equal(t, LogOutcomeError, got[0]["outcome"])
+		assert.Equal(t, "failed", got[0]["error"])
+	})
+
+	t.Run("panicsAreLoggedAndPropagated", func(t *testing.T) {
+		buf := captureLogs(t)
+		m, _ := Log("unit-tests", "test", LogConfig{})
+
+		assert.Panics(t, func() {
+			m(func(context.Context, *nats.Msg) { panic("boom") })(context.Background(), newMsg("log.unit-tests", nil))
+		})
+
+		got := entries(t, buf)
+		assert.Equal(t, LogOutcomePanic, got[1]["outcome"])
+		assert.Equal(t, "boom", got[1]["error"])
+	})
+
+	t.Run("invalidLevel", func(t *testing.T) {
+		_, err := Log("unit-tests", "test", LogConfig{Level: "loud"})
+		assert.NotNil(t, err)
+	})
+}
@@ -2,6 +2,7 @@ package nats
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/nats-io/nats.go"
@@ -14,6 +15,9 @@ type Msg = nats.Msg
 // Subscription alias here - same reason as for Msg
 type Subscription = nats.Subscription
 
+// Header alias here - same reason as for Msg
+type Header = nats.Header
+
 // Config represents the NATS client configuration
 type Config struct {
 	URL     string
@@ -27,9 +31,27 @@ type Config struct {
 // TLSConfig represents the TLS part of NATS client configuration
 type TLSConfig struct {
 	Enabled bool
-	Cert    string
-	Key     string
-	CA      string
+	// Cert and Key are the client certificate files.
+	// Leave both empty when the server does not require client authentication.
+	Cert string
+	Key  string
+	// CA is the file with the root CAs used to verify the server.
+	// When empty the system roots are used.
+	CA string
+	// ServerName overrides the host name used to verify the server certificate.
+	ServerName string
+	// MinVersion is the minimum accepted TLS version ("1.2" by default or "1.3").
+	MinVersion string
+	// CipherSuites restricts the TLS 1.2 cipher suites, using their Go names
+	// (e.g. TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256).
+	CipherSuites []string
+	// HandshakeFirst performs the TLS handshake before the server sends the INFO protocol.
+	// The server must be configured with handshake_first as well.
+	HandshakeFirst bool
+	// ReloadInterval is how often Cert, Key and CA are checked for changes.
+	// When a change is detected the connection is re-established with the new files.
+	// Zero disables reloading.
+	ReloadInterval time.Duration
 }
 
 // Options represents the NATS client options configuration
@@ -41,6 +63,7 @@ type Options struct {
 type client struct {
 	cfg Config
 	nc  *nats.Conn
+	tls *certReloader
 }
 
 // Client is a custom wrapper on top of nats-go pkg
@@ -97,14 +120,26 @@ func (client *client) Connect() error {
 		options = append(options, nats.UserInfo(client.cfg.User, client.cfg.Pass))
 	}
 	if client.cfg.TLS.Enabled {
-		options = append(
-			options,
-			nats.ClientCert(client.cfg.TLS.Cert, client.cfg.TLS.Key),
-			nats.RootCAs(client.cfg.TLS.CA),
-		)
+		var tlsOpts []nats.Option
+		tlsOpts, client.tls, err = tlsOptions(client.cfg.TLS)
+		if err != nil {
+			return err
+		}
+		options = append(options, tlsOpts...)
 	}
 	client.nc, err = nats.Connect(client.cfg.URL, options...)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if client.tls != nil && client.cfg.TLS.ReloadInterval > 0 {
+		nc := client.nc
+		go client.tls.watch(client.cfg.TLS.ReloadInterval, func() {
+			_ = nc.ForceReconnect()
+		})
+	}
+
+	return nil
 }
 
 // GetConn returns current NATS connection
@@ -173,19 +208,9 @@ func (client client) Publish(subject string, data []byte) error {
 // - retry 1 will be made with a delay of 1 second
 // - retry 2 will be made with a delay of 2 seconds
 func (client client) PublishWithRetries(subject string, data []byte, retries int) (int, error) {
-	var err error
-	i := 0
-	for {
-		err = client.Publish(subject, data)
-		if err == nil {
-			return i, nil
-		}
-		i++
-		if i >= retries {
-			return i, err
-		}
-		time.Sleep(time.Duration(i) * time.Second)
-	}
+	return Retry(retries, func() error {
+		return client.Publish(subject, data)
+	})
 }
 
 // PublishMsg publishes a Msg structure
@@ -200,19 +225,9 @@ func (client client) PublishMsg(msg *Msg) error {
 // - retry 1 will be made with a delay of 1 second
 // - retry 2 will be made with a delay of 2 seconds
 func (client client) PublishMsgWithRetries(msg *Msg, retries int) (int, error) {
-	var err error
-	i := 0
-	for {
-		err = client.PublishMsg(msg)
-		if err == nil {
-			return i, nil
-		}
-		i++
-		if i >= retries {
-			return i, err
-		}
-		time.Sleep(time.Duration(i) * time.Second)
-	}
+	return Retry(retries, func() error {
+		return client.PublishMsg(msg)
+	})
 }
 
 // RequestMsg wrapper for RequestMsg
@@ -227,26 +242,69 @@ func (client client) RequestMsg(msg *Msg, timeout time.Duration) (*Msg, error) {
 // - retry 1 will be made with a delay of 1 second
 // - retry 2 will be made with a delay of 2 seconds
 func (client client) RequestMsgWithRetries(msg *Msg, timeout time.Duration, retries int) (*Msg, int, error) {
-	var err error
+	var responseMsg *Msg
+	i, err := Retry(retries, func() error {
+		var err error
+		responseMsg, err = client.RequestMsg(msg, timeout)
+		return err
+	})
+	if err != nil {
+		return nil, i, err
+	}
+
+	return responseMsg, i, nil
+}
+
+// Close terminates the connection to the NATS server and releases all blocking calls
+func (client client) Close() {
+	if client.tls != nil {
+		client.tls.close()
+	}
+	_ = client.nc.Flush()
+	client.nc.Close()
+}
+
+// Retry calls fn until it succeeds or it was called retries times, using exponential backoff.
+// It returns the number of failed calls and the last error.
+// E.g. retries = 3
+// - main call will be made with a delay of 0 seconds
+// - retry 1 will be made with a delay of 1 second
+// - retry 2 will be made with a delay of 2 seconds
+// Errors wrapped with Permanent are returned without retrying.
+func Retry(retries int, fn func() error) (int, error) {
 	i := 0
 	for {
-		var responseMsg *Msg
-		responseMsg, err = client.RequestMsg(msg, timeout)
+		err := fn()
 		if err == nil {
-			return responseMsg, i, nil
+			return i, nil
 		}
 		i++
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return i, permanent.err
+		}
 		if i >= retries {
-			return nil, i, err
+			return i, err
 		}
 		time.Sleep(time.Duration(i) * time.Second)
 	}
 }
 
-// Close terminates the connection to the NATS server and releases all blocking calls
-func (client client) Close() {
-	_ = client.nc.Flush()
-	client.nc.Close()
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err so Retry returns it without retrying
+func Permanent(err error) error {
+	return &permanentError{err: err}
 }
 
 // ReadMsg gets next message from subscription
@@ -331,3 +331,24 @@ func TestClient_Close(t *testing.T) {
 		t.Errorf("got = %v, want = %v", c.IsConnected(), false)
 	}
 }
+
+func TestRetry(t *testing.T) {
+	t.Run("no retries are need", func(t *testing.T) {
+		got, err := Retry(3, func() error { return nil })
+		if err != nil || got != 0 {
+			t.Errorf("got = %v, %v, want = %v, %v", got, err, 0, nil)
+		}
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		calls := 0
+		want := fmt.Errorf("unit-tests")
+		got, err := Retry(3, func() error {
+			calls++
+			return Permanent(want)
+		})
+		if err != want || got != 1 || calls != 1 {
+			t.Errorf("got = %v, %v, %v, want = %v, %v, %v", got, err, calls, 1, want, 1)
+		}
+	})
+}
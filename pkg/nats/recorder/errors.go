@@ -0,0 +1,12 @@
+package recorder
+
+import "errors"
+
+var (
+	// ErrInvalidFormat is returned when reading a file that is not a valid recording
+	ErrInvalidFormat = errors.New("invalid recording format")
+	// ErrNoSubjects is returned when recording without subjects
+	ErrNoSubjects = errors.New("no subjects to record")
+	// ErrInvalidSpeed is returned for negative or unparsable replay speeds
+	ErrInvalidSpeed = errors.New("invalid replay speed")
+)
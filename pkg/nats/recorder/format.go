@@ -0,0 +1,182 @@
+package recorder
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/ventive/go-mono-template/pkg/nats"
+)
+
+// magic identifies recording files and the version of the format
+var magic = []byte("NATSREC\x01")
+
+// maxFieldSize protects the reader against corrupted length prefixes
+const maxFieldSize = 64 * 1024 * 1024
+
+// Message is a single recorded message
+type Message struct {
+	Time    time.Time
+	Subject string
+	Header  nats.Header
+	Data    []byte
+}
+
+// Writer writes records to a recording file.
+//
+// The file starts with a magic header followed by the records.
+// Every record is encoded as: varint unix nano timestamp, subject, header count,
+// header keys with their values and the data. Strings and byte slices are
+// prefixed with their uvarint length.
+type Writer struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+// NewWriter writes the file header to w and returns a Writer
+func NewWriter(w io.Writer) (*Writer, error) {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(magic); err != nil {
+		return nil, err
+	}
+
+	return &Writer{w: bw}, nil
+}
+
+// Write appends a message. It might be buffered until Flush is called.
+func (w *Writer) Write(record Message) error {
+	buf := binary.AppendVarint(w.buf[:0], record.Time.UnixNano())
+	buf = appendBytes(buf, []byte(record.Subject))
+
+	keys := make([]string, 0, len(record.Header))
+	for k := range record.Header {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	buf = binary.AppendUvarint(buf, uint64(len(keys)))
+	for _, k := range keys {
+		buf = appendBytes(buf, []byte(k))
+		buf = binary.AppendUvarint(buf, uint64(len(record.Header[k])))
+		for _, v := range record.Header[k] {
+			buf = appendBytes(buf, []byte(v))
+		}
+	}
+	buf = appendBytes(buf, record.Data)
+	w.buf = buf
+
+	_, err := w.w.Write(buf)
+
+	return err
+}
+
+// Flush writes the buffered records to the underlying writer
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+func appendBytes(buf, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+
+	return append(buf, b...)
+}
+
+// Reader reads records from a recording file
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader checks the file header of r and returns a Reader
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, header); err != nil || string(header) != string(magic) {
+		return nil, ErrInvalidFormat
+	}
+
+	return &Reader{r: br}, nil
+}
+
+// Next returns the next record or io.EOF when there are no more records
+func (r *Reader) Next() (Message, error) {
+	var record Message
+
+	ts, err := binary.ReadVarint(r.r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return record, io.EOF
+		}
+
+		return record, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+	record.Time = time.Unix(0, ts)
+
+	subject, err := r.readBytes()
+	if err != nil {
+		return record, err
+	}
+	record.Subject = string(subject)
+
+	keys, err := r.readUvarint()
+	if err != nil {
+		return record, err
+	}
+	if keys > 0 {
+		record.Header = make(nats.Header, keys)
+	}
+	for range keys {
+		key, err := r.readBytes()
+		if err != nil {
+			return record, err
+		}
+
+		values, err := r.readUvarint()
+		if err != nil {
+			return record, err
+		}
+		for range values {
+			value, err := r.readBytes()
+			if err != nil {
+				return record, err
+			}
+			record.Header[string(key)] = append(record.Header[string(key)], string(value))
+		}
+	}
+
+	if record.Data, err = r.readBytes(); err != nil {
+		return record, err
+	}
+
+	return record, nil
+}
+
+func (r *Reader) readUvarint() (uint64, error) {
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+
+	return n, nil
+}
+
+func (r *Reader) readBytes() ([]byte, error) {
+	n, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > maxFieldSize {
+		return nil, fmt.Errorf("%w: field of %d bytes", ErrInvalidFormat, n)
+	}
+
+	b := make([]byte, n)
+	if _, err = io.ReadFull(r.r, b); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+
+	return b, nil
+}
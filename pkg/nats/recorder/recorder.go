@@ -0,0 +1,162 @@
+// Package recorder records NATS traffic to a file and replays it.
+//
+// It is meant for reproducing production issues against a local NATS server.
+package recorder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ventive/go-mono-template/pkg/nats"
+)
+
+// SpeedMax replays the messages as fast as possible
+const SpeedMax = 0
+
+// received is a message with the time it was received at
+type received struct {
+	msg *nats.Msg
+	at  time.Time
+}
+
+// Record subscribes to the subjects and writes every received message to w until ctx is done.
+// Subjects can contain wildcards. The messages received before ctx is done are all written.
+// It returns the number of recorded messages.
+func Record(ctx context.Context, client nats.Client, subjects []string, w *Writer) (int, error) {
+	if len(subjects) == 0 {
+		return 0, ErrNoSubjects
+	}
+
+	msgs := make(chan received, 1024)
+	subs := make([]*nats.Subscription, 0, len(subjects))
+	unsubscribe := func() {
+		for _, sub := range subs {
+			_ = client.Unsubscribe(sub)
+		}
+		subs = nil
+	}
+	defer unsubscribe()
+
+	for _, subject := range subjects {
+		sub, err := client.Subscribe(subject, func(msg *nats.Msg) {
+			select {
+			case msgs <- received{msg: msg, at: time.Now()}:
+			case <-ctx.Done():
+			}
+		})
+		if err != nil {
+			return 0, err
+		}
+		subs = append(subs, sub)
+	}
+
+	count := 0
+	write := func(r received) error {
+		err := w.Write(Message{
+			Time:    r.at,
+			Subject: r.msg.Subject,
+			Header:  r.msg.Header,
+			Data:    r.msg.Data,
+		})
+		if err == nil {
+			count++
+		}
+
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			unsubscribe()
+			// the messages already received are kept
+			for {
+				select {
+				case r := <-msgs:
+					if err := write(r); err != nil {
+						return count, err
+					}
+				default:
+					return count, w.Flush()
+				}
+			}
+		case r := <-msgs:
+			if err := write(r); err != nil {
+				return count, err
+			}
+		}
+	}
+}
+
+// Replay publishes the records read from r with their original subject, headers and data.
+//
+// speed scales the original delays between the messages: 1 keeps them,
+// 2 replays twice as fast and SpeedMax does not wait at all.
+// It returns the number of replayed messages.
+func Replay(ctx context.Context, client nats.Client, r *Reader, speed float64) (int, error) {
+	if speed < 0 {
+		return 0, ErrInvalidSpeed
+	}
+
+	var first time.Time
+	start := time.Now()
+	count := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+
+		record, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		if first.IsZero() {
+			first = record.Time
+		}
+		if speed != SpeedMax {
+			offset := time.Duration(float64(record.Time.Sub(first)) / speed)
+			if wait := time.Until(start.Add(offset)); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return count, ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		msg := nats.NewMsg(record.Subject)
+		for k, v := range record.Header {
+			msg.Header[k] = v
+		}
+		msg.Data = record.Data
+		if err = client.PublishMsg(msg); err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+// ParseSpeed converts "original", "max" or a multiplier like "2" or "0.5" to a Replay speed
+func ParseSpeed(speed string) (float64, error) {
+	switch strings.ToLower(speed) {
+	case "", "original":
+		return 1, nil
+	case "max":
+		return SpeedMax, nil
+	}
+
+	s, err := strconv.ParseFloat(strings.TrimSuffix(speed, "x"), 64)
+	if err != nil || s <= 0 {
+		return 0, ErrInvalidSpeed
+	}
+
+	return s, nil
+}
@@ -0,0 +1,194 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ventive/go-mono-template/pkg/nats"
+)
+
+func runServer() (string, func()) {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	s := natsserver.RunServer(&opts)
+
+	return s.ClientURL(), s.Shutdown
+}
+
+func connect(t *testing.T, url string) nats.Client {
+	c := nats.NewClient(nats.Config{URL: url, Name: "unit-tests"})
+	if err := c.Connect(); err != nil {
+		t.Fatalf("unexpected error when establish the connection to NATS, error = %v", err)
+	}
+
+	return c
+}
+
+func TestWriterReader(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		messages := []Message{
+			{Time: time.Unix(0, 10), Subject: "a.b", Header: nats.Header{"X-Key": {"1", "2"}, "Y": {""}}, Data: []byte(`{"data":{}}`)},
+			{Time: time.Unix(5, 0), Subject: "c", Data: []byte{}},
+		}
+
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf)
+		assert.Nil(t, err)
+		for _, m := range messages {
+			assert.Nil(t, w.Write(m))
+		}
+		assert.Nil(t, w.Flush())
+
+		r, err := NewReader(&buf)
+		assert.Nil(t, err)
+		for _, want := range messages {
+			got, err := r.Next()
+			assert.Nil(t, err)
+			assert.True(t, want.Time.Equal(got.Time))
+			assert.Equal(t, want.Subject, got.Subject)
+			assert.Equal(t, want.Header, got.Header)
+			assert.Equal(t, want.Data, got.Data)
+		}
+		_, err = r.Next()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("returnsErrWhenNotARecording", func(t *testing.T) {
+		_, err := NewReader(bytes.NewBufferString("not a recording"))
+		assert.Equal(t, ErrInvalidFormat, err)
+	})
+
+	t.Run("returnsErrWhenTruncated", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, _ := NewWriter(&buf)
+		_ = w.Write(Message{Subject: "a", Data: []byte("abcd")})
+		_ = w.Flush()
+
+		r, _ := NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-2]))
+		_, err := r.Next()
+		assert.True(t, errors.Is(err, ErrInvalidFormat))
+	})
+}
+
+func TestParseSpeed(t *testing.T) {
+	tests := []struct {
+		speed   string
+		want    float64
+		wantErr bool
+	}{
+		{"", 1, false},
+		{"original", 1, false},
+		{"max", SpeedMax, false},
+		{"2", 2, false},
+		{"0.5x", 0.5, false},
+		{"0", 0, true},
+		{"fast", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.speed, func(t *testing.T) {
+			got, err := ParseSpeed(tt.speed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSpeed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	url, shutdown := runServer()
+	defer shutdown()
+
+	c := connect(t, url)
+	defer c.Close()
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	done := make(chan int)
+	go func() {
+		count, err := Record(ctx, c, []string{"unit-tests.>"}, w)
+		assert.Nil(t, err)
+		done <- count
+	}()
+
+	// give the subscription the time to be registered
+	time.Sleep(50 * time.Millisecond)
+	publisher := connect(t, url)
+	defer publisher.Close()
+	msg := nats.NewMsgWithHeaders("unit-tests.in", map[string]string{"X-Id": "1"})
+	msg.Data = []byte("first")
+	_ = publisher.PublishMsg(msg)
+	_ = publisher.Publish("unit-tests.in", []byte("second"))
+	_ = publisher.Publish("ignored", []byte("third"))
+	_ = publisher.GetConn().Flush()
+	time.Sleep(50 * time.Millisecond)
+
+	cancelFunc()
+	assert.Equal(t, 2, <-done)
+
+	sub, err := c.SubscribeSync("unit-tests.>")
+	require.NoError(t, err)
+	// the subscription must be registered before the replay publishes over the other connection
+	require.NoError(t, c.GetConn().Flush())
+
+	r, err := NewReader(&buf)
+	require.NoError(t, err)
+	count, err := Replay(context.Background(), publisher, r, SpeedMax)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	got, err := sub.NextMsg(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(got.Data))
+	assert.Equal(t, "1", got.Header.Get("X-Id"))
+	got, err = sub.NextMsg(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(got.Data))
+}
+
+// cancelingClient cancels the replay once it published after messages
+type cancelingClient struct {
+	nats.Client
+	after     int
+	published int
+	cancel    context.CancelFunc
+}
+
+func (c *cancelingClient) PublishMsg(*nats.Msg) error {
+	c.published++
+	if c.published == c.after {
+		c.cancel()
+	}
+
+	return nil
+}
+
+func TestReplayCanceled(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, w.Write(Message{Time: time.Now(), Subject: "replay.unit-tests"}))
+	}
+	require.NoError(t, w.Flush())
+	r, err := NewReader(&buf)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &cancelingClient{after: 2, cancel: cancel}
+
+	count, err := Replay(ctx, client, r, SpeedMax)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 2, client.published)
+}
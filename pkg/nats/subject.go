@@ -0,0 +1,25 @@
+package nats
+
+import "strings"
+
+// SubjectMatches reports whether subject matches pattern.
+// The pattern uses the NATS wildcards: "*" matches a single token and
+// ">" matches one or more trailing tokens.
+func SubjectMatches(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	for i, token := range patternTokens {
+		if token == ">" {
+			return i == len(patternTokens)-1 && len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) {
+			return false
+		}
+		if token != "*" && token != subjectTokens[i] {
+			return false
+		}
+	}
+
+	return len(patternTokens) == len(subjectTokens)
+}
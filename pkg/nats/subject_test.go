@@ -0,0 +1,29 @@
+package nats
+
+import "testing"
+
+func TestSubjectMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		subject string
+		want    bool
+	}{
+		{"a.b.c", "a.b.c", true},
+		{"a.b.c", "a.b", false},
+		{"a.b", "a.b.c", false},
+		{"a.*.c", "a.b.c", true},
+		{"a.*", "a.b.c", false},
+		{"a.>", "a.b.c", true},
+		{"a.>", "a", false},
+		{">", "a", true},
+		{"*", "a.b", false},
+		{"a.>.c", "a.b.c", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.subject, func(t *testing.T) {
+			if got := SubjectMatches(tt.pattern, tt.subject); got != tt.want {
+				t.Errorf("got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
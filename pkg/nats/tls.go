@@ -0,0 +1,224 @@
+package nats
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ventive/go-mono-template/pkg/logger"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsOptions builds the nats.go options matching the TLS configuration.
+// The returned reloader is nil when there are no files to load.
+func tlsOptions(cfg TLSConfig) ([]nats.Option, *certReloader, error) {
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	options := []nats.Option{nats.Secure(tlsConfig)}
+	if cfg.HandshakeFirst {
+		options = append(options, nats.TLSHandshakeFirst())
+	}
+
+	if cfg.Cert == "" && cfg.CA == "" {
+		// server verification against the system roots only
+		return options, nil, nil
+	}
+
+	reloader, err := newCertReloader(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the callbacks are evaluated on every (re)connect, so a reloaded
+	// certificate is used as soon as the connection is re-established
+	var certCB nats.TLSCertHandler
+	if cfg.Cert != "" {
+		certCB = reloader.clientCert
+	}
+	var rootCAsCB nats.RootCAsHandler
+	if cfg.CA != "" {
+		rootCAsCB = reloader.rootCAs
+	}
+
+	return append(options, nats.ClientTLSConfig(certCB, rootCAsCB)), reloader, nil
+}
+
+// newTLSConfig validates cfg and creates the base tls.Config used for every connection
+func newTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	if (cfg.Cert == "") != (cfg.Key == "") {
+		return nil, ErrTLSCertKeyMismatch
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+
+	if cfg.MinVersion != "" {
+		version, ok := tlsVersions[cfg.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrTLSUnknownVersion, cfg.MinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if len(cfg.CipherSuites) > 0 {
+		suites := make(map[string]uint16)
+		for _, suite := range tls.CipherSuites() {
+			suites[suite.Name] = suite.ID
+		}
+
+		for _, name := range cfg.CipherSuites {
+			id, ok := suites[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrTLSUnknownCipherSuite, name)
+			}
+			tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// certReloader keeps the client certificate and root CAs loaded from disk
+// and reloads them when the files change.
+type certReloader struct {
+	cfg TLSConfig
+
+	mu       sync.RWMutex
+	cert     tls.Certificate
+	pool     *x509.CertPool
+	checksum []byte
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+func newCertReloader(cfg TLSConfig) (*certReloader, error) {
+	reloader := &certReloader{
+		cfg:  cfg,
+		stop: make(chan struct{}),
+	}
+	if _, err := reloader.reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+func (r *certReloader) clientCert() (tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+func (r *certReloader) rootCAs() (*x509.CertPool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.pool, nil
+}
+
+// reload reads the files again and swaps the loaded material when their content changed.
+// On error the previously loaded material is kept.
+func (r *certReloader) reload() (bool, error) {
+	hash := sha256.New()
+
+	var certPEM, keyPEM, caPEM []byte
+	var err error
+	if r.cfg.Cert != "" {
+		if certPEM, err = os.ReadFile(r.cfg.Cert); err != nil {
+			return false, fmt.Errorf("%w: %w", ErrTLSLoadFailed, err)
+		}
+		if keyPEM, err = os.ReadFile(r.cfg.Key); err != nil {
+			return false, fmt.Errorf("%w: %w", ErrTLSLoadFailed, err)
+		}
+	}
+	if r.cfg.CA != "" {
+		if caPEM, err = os.ReadFile(r.cfg.CA); err != nil {
+			return false, fmt.Errorf("%w: %w", ErrTLSLoadFailed, err)
+		}
+	}
+	hash.Write(certPEM)
+	hash.Write(keyPEM)
+	hash.Write(caPEM)
+	checksum := hash.Sum(nil)
+
+	r.mu.RLock()
+	unchanged := bytes.Equal(checksum, r.checksum)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	var cert tls.Certificate
+	if certPEM != nil {
+		if cert, err = tls.X509KeyPair(certPEM, keyPEM); err != nil {
+			return false, fmt.Errorf("%w: %w", ErrTLSLoadFailed, err)
+		}
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return false, fmt.Errorf("%w: %w", ErrTLSLoadFailed, err)
+		}
+	}
+
+	var pool *x509.CertPool
+	if caPEM != nil {
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return false, fmt.Errorf("%w: no certificate found in %q", ErrTLSLoadFailed, r.cfg.CA)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.pool, r.checksum = cert, pool, checksum
+	r.mu.Unlock()
+
+	return true, nil
+}
+
+// watch checks the files every interval and calls onChange after a successful reload.
+// It returns when close is called.
+func (r *certReloader) watch(interval time.Duration, onChange func()) {
+	log := logger.New("nats", "certReloader.watch")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			changed, err := r.reload()
+			if err != nil {
+				log.Error("Could not reload TLS files, keeping the previous ones", err)
+				continue
+			}
+			if changed {
+				log.Info("TLS files changed, reconnecting")
+				onChange()
+			}
+		}
+	}
+}
+
+func (r *certReloader) close() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+}
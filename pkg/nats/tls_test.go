@@ -0,0 +1,195 @@
+package nats
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/test"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "unit-tests-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	return testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM encoded certificate and key signed by the CA
+func (ca testCA) issue(t *testing.T, serial int64, host string) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     TLSConfig
+		wantErr error
+		want    uint16
+	}{
+		{"defaults to tls 1.2", TLSConfig{}, nil, tls.VersionTLS12},
+		{"min version", TLSConfig{MinVersion: "1.3"}, nil, tls.VersionTLS13},
+		{"unknown min version", TLSConfig{MinVersion: "2.0"}, ErrTLSUnknownVersion, 0},
+		{"cert without key", TLSConfig{Cert: "cert.pem"}, ErrTLSCertKeyMismatch, 0},
+		{"known cipher suite", TLSConfig{CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}}, nil, tls.VersionTLS12},
+		{"unknown cipher suite", TLSConfig{CipherSuites: []string{"TLS_NOPE"}}, ErrTLSUnknownCipherSuite, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTLSConfig(tt.cfg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("newTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.MinVersion != tt.want {
+				t.Errorf("got = %v, want = %v", got.MinVersion, tt.want)
+			}
+		})
+	}
+}
+
+func TestCertReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+
+	certPEM, keyPEM := ca.issue(t, 2, "client")
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	writeFile(t, caFile, ca.pem)
+
+	r, err := newCertReloader(TLSConfig{Cert: certFile, Key: keyFile, CA: caFile})
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+
+	changed, err := r.reload()
+	if err != nil || changed {
+		t.Fatalf("got = %v, %v, want = false, nil", changed, err)
+	}
+
+	certPEM, keyPEM = ca.issue(t, 3, "client")
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+
+	changed, err = r.reload()
+	if err != nil || !changed {
+		t.Fatalf("got = %v, %v, want = true, nil", changed, err)
+	}
+	cert, _ := r.clientCert()
+	if cert.Leaf.SerialNumber.Int64() != 3 {
+		t.Errorf("got = %v, want = %v", cert.Leaf.SerialNumber, 3)
+	}
+
+	// a broken file keeps the previous certificate
+	writeFile(t, keyFile, []byte("broken"))
+	if _, err = r.reload(); !errors.Is(err, ErrTLSLoadFailed) {
+		t.Fatalf("unexpected error = %v", err)
+	}
+	cert, _ = r.clientCert()
+	if cert.Leaf.SerialNumber.Int64() != 3 {
+		t.Errorf("got = %v, want = %v", cert.Leaf.SerialNumber, 3)
+	}
+}
+
+func TestClient_ConnectTLSWithCAOnly(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := filepath.Join(dir, "ca.pem")
+	writeFile(t, caFile, ca.pem)
+
+	certPEM, keyPEM := ca.issue(t, 2, "nats.unit-tests")
+	serverCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("unexpected error = %v", err)
+	}
+
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	opts.TLS = true
+	opts.TLSConfig = &tls.Config{Certificates: []tls.Certificate{serverCert}, MinVersion: tls.VersionTLS12}
+	s := natsserver.RunServer(&opts)
+	defer s.Shutdown()
+
+	c := NewClient(Config{
+		URL:  fmt.Sprintf("tls://%s", s.Addr().String()),
+		Name: "unit-tests",
+		TLS: TLSConfig{
+			Enabled:        true,
+			CA:             caFile,
+			ServerName:     "nats.unit-tests",
+			ReloadInterval: time.Millisecond,
+		},
+	})
+	if err = c.Connect(); err != nil {
+		t.Fatalf("unexpected error when establish the connection to NATS, error = %v", err)
+	}
+	defer c.Close()
+
+	if !c.IsConnected() {
+		t.Errorf("got = %v, want = %v", c.IsConnected(), true)
+	}
+}
@@ -0,0 +1,6 @@
+package tracing
+
+import "errors"
+
+// ErrUnknownExporter is returned by Init for unsupported exporters
+var ErrUnknownExporter = errors.New("unknown tracing exporter")
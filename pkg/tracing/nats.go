@@ -0,0 +1,169 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ventive/go-mono-template/pkg/nats"
+)
+
+var messagingSystemNATS = semconv.MessagingSystemKey.String("nats")
+
+// HeaderCarrier adapts nats.Header to a propagation.TextMapCarrier
+type HeaderCarrier nats.Header
+
+// Get godoc
+func (c HeaderCarrier) Get(key string) string {
+	return nats.Header(c).Get(key)
+}
+
+// Set godoc
+func (c HeaderCarrier) Set(key, value string) {
+	nats.Header(c).Set(key, value)
+}
+
+// Keys godoc
+func (c HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// Extract returns a copy of ctx holding the trace context found in msg headers
+func Extract(ctx context.Context, msg *nats.Msg) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, HeaderCarrier(msg.Header))
+}
+
+// Inject writes the trace context of ctx in msg headers
+func Inject(ctx context.Context, msg *nats.Msg) {
+	if msg.Header == nil {
+		msg.Header = nats.Header{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, HeaderCarrier(msg.Header))
+}
+
+// StartConsumerSpan starts the span processing msg, as a child of the trace context found in its headers
+func StartConsumerSpan(ctx context.Context, msg *nats.Msg, queueGroup string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		messagingSystemNATS,
+		semconv.MessagingOperationTypeProcess,
+		semconv.MessagingOperationName("process"),
+		semconv.MessagingDestinationName(msg.Subject),
+		semconv.MessagingMessageBodySize(len(msg.Data)),
+	}
+	if queueGroup != "" {
+		attrs = append(attrs, semconv.MessagingConsumerGroupName(queueGroup))
+	}
+
+	return Tracer().Start(Extract(ctx, msg), "process "+msg.Subject,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// StartProducerSpan starts the span sending msg and injects its trace context in msg headers
+func StartProducerSpan(ctx context.Context, msg *nats.Msg) (context.Context, trace.Span) {
+	ctx, span := Tracer().Start(ctx, "send "+msg.Subject,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			messagingSystemNATS,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingOperationName("send"),
+			semconv.MessagingDestinationName(msg.Subject),
+			semconv.MessagingMessageBodySize(len(msg.Data)),
+		),
+	)
+	Inject(ctx, msg)
+
+	return ctx, span
+}
+
+// End records err on span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type client struct {
+	nats.Client
+}
+
+// WrapClient returns a nats.Client starting a producer span for every published message.
+//
+// The parent of the span is the trace context already present in the message headers,
+// so messages built from the headers of a traced incoming message continue its trace.
+func WrapClient(c nats.Client) nats.Client {
+	return &client{Client: c}
+}
+
+func (c *client) startSpan(msg *nats.Msg) trace.Span {
+	_, span := StartProducerSpan(Extract(context.Background(), msg), msg)
+
+	return span
+}
+
+// Publish publishes data as a message with headers carrying the trace context
+func (c *client) Publish(subject string, data []byte) error {
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+
+	return c.PublishMsg(msg)
+}
+
+// PublishWithRetries publishes data as a message with headers carrying the trace context
+func (c *client) PublishWithRetries(subject string, data []byte, retries int) (int, error) {
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+
+	return c.PublishMsgWithRetries(msg, retries)
+}
+
+// PublishMsg godoc
+func (c *client) PublishMsg(msg *nats.Msg) error {
+	span := c.startSpan(msg)
+	err := c.Client.PublishMsg(msg)
+	End(span, err)
+
+	return err
+}
+
+// PublishMsgWithRetries traces all the attempts in a single span
+func (c *client) PublishMsgWithRetries(msg *nats.Msg, retries int) (int, error) {
+	span := c.startSpan(msg)
+	i, err := c.Client.PublishMsgWithRetries(msg, retries)
+	span.SetAttributes(attribute.Int("messaging.nats.retries", i))
+	End(span, err)
+
+	return i, err
+}
+
+// RequestMsg godoc
+func (c *client) RequestMsg(msg *nats.Msg, timeout time.Duration) (*nats.Msg, error) {
+	span := c.startSpan(msg)
+	resp, err := c.Client.RequestMsg(msg, timeout)
+	End(span, err)
+
+	return resp, err
+}
+
+// RequestMsgWithRetries traces all the attempts in a single span
+func (c *client) RequestMsgWithRetries(msg *nats.Msg, timeout time.Duration, retries int) (*nats.Msg, int, error) {
+	span := c.startSpan(msg)
+	resp, i, err := c.Client.RequestMsgWithRetries(msg, timeout, retries)
+	span.SetAttributes(attribute.Int("messaging.nats.retries", i))
+	End(span, err)
+
+	return resp, i, err
+}
@@ -0,0 +1,108 @@
+// Package tracing sets up OpenTelemetry tracing and propagates the W3C trace context
+// (traceparent and tracestate) in NATS message headers.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/ventive/go-mono-template/pkg/tracing"
+
+// Exporters supported by Init
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterMemory = "memory"
+)
+
+// Config godoc
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Exporter is one of otlp, stdout or memory
+	Exporter string `mapstructure:"exporter"`
+	// Endpoint of the OTLP HTTP collector (e.g. localhost:4318)
+	Endpoint string `mapstructure:"endpoint"`
+	// Insecure disables TLS when talking to the OTLP collector
+	Insecure bool `mapstructure:"insecure"`
+	// SampleRatio of the traces started by the service, between 0 and 1.
+	// Traces started upstream follow the sampling decision of their parent.
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+var memoryExporter = tracetest.NewInMemoryExporter()
+
+// MemoryExporter returns the exporter holding the spans when the memory exporter is used
+func MemoryExporter() *tracetest.InMemoryExporter {
+	return memoryExporter
+}
+
+// Init registers the global tracer provider and the W3C trace context propagator.
+// The returned function flushes the pending spans and must be called before exiting.
+// When tracing is disabled nothing is registered and the spans are no-ops.
+func Init(ctx context.Context, serviceName string, cfg Config) (func(context.Context) error, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var processor sdktrace.SpanProcessor
+	switch strings.ToLower(cfg.Exporter) {
+	case ExporterOTLP:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, options...)
+		if err != nil {
+			return nil, err
+		}
+		processor = sdktrace.NewBatchSpanProcessor(exporter)
+	case ExporterStdout:
+		exporter, err := stdouttrace.New()
+		if err != nil {
+			return nil, err
+		}
+		processor = sdktrace.NewSimpleSpanProcessor(exporter)
+	case ExporterMemory:
+		processor = sdktrace.NewSimpleSpanProcessor(memoryExporter)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, cfg.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer used for the NATS spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
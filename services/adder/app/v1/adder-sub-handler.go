@@ -1,44 +1,121 @@
 package v1
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 
 	"github.com/ventive/go-mono-template/internal/types"
 	"github.com/ventive/go-mono-template/internal/types/adder"
-	"github.com/ventive/go-mono-template/pkg/decoder"
+	"github.com/ventive/go-mono-template/pkg/decimal"
 	"github.com/ventive/go-mono-template/pkg/logger"
 	"github.com/ventive/go-mono-template/pkg/nats"
+	"github.com/ventive/go-mono-template/pkg/nats/middleware"
 )
 
-func (a *App) addHandler(msg *nats.Msg) {
-	log := logger.New(appID, "App.subtractHandler")
+// addHandler replies with the result as a bare number, or a string in decimal arithmetic
+func (a *App) addHandler(ctx context.Context, msg *nats.Msg) {
+	log := logger.FromContext(ctx).WithAction("App.addHandler")
 	log.Info("New Event")
-	var event types.InputEvent
 
-	err := json.Unmarshal(msg.Data, &event)
+	if a.decimalArithmetic(msg) {
+		if result, ok := a.decimalAdd(ctx, log, msg); ok {
+			a.subHandlerReturn(ctx, log, nil, msg, result)
+		}
+		return
+	}
+
+	event, ok := decodedEvent[adder.AddEvent](ctx, a, log, msg)
+	if !ok {
+		return
+	}
+
+	a.subHandlerReturn(ctx, log, nil, msg, a.processAddEvent(ctx, event))
+}
+
+// addHandlerV2 replies with the result in a adder.AddResult object,
+// or a adder.DecimalAddResult in decimal arithmetic
+func (a *App) addHandlerV2(ctx context.Context, msg *nats.Msg) {
+	log := logger.FromContext(ctx).WithAction("App.addHandlerV2")
+	log.Info("New Event")
+
+	if a.decimalArithmetic(msg) {
+		if result, ok := a.decimalAdd(ctx, log, msg); ok {
+			a.subHandlerReturn(ctx, log, nil, msg, adder.DecimalAddResult{Result: result})
+		}
+		return
+	}
+
+	event, ok := decodedEvent[adder.AddEvent](ctx, a, log, msg)
+	if !ok {
+		return
+	}
+
+	a.subHandlerReturn(ctx, log, nil, msg, adder.AddResult{Result: a.processAddEvent(ctx, event)})
+}
+
+// decodedEvent returns the event of msg, or replies with the reason it cannot be processed
+func decodedEvent[T any](ctx context.Context, a *App, log *logger.Logger, msg *nats.Msg) (T, bool) {
+	var event T
+
+	// the caller is no longer waiting for the result
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		log.Warn("Deadline exceeded, skipping event")
+		a.subHandlerReturn(ctx, log, ctx.Err(), msg, nil)
+		return event, false
+	}
+
+	// the messages without envelope are still accepted
+	if _, err := types.ParseCloudEvent(msg); err != nil && !errors.Is(err, types.ErrNotCloudEvent) {
+		log.Error("Invalid CloudEvent", err)
+		a.subHandlerReturn(ctx, log, err, msg, nil)
+		return event, false
+	}
+
+	// decoded and validated by the validate middleware
+	event, err := middleware.Decoded[T](ctx)
 	if err != nil {
-		log.Error("Could not decode event", err)
+		log.Error("Could not get the decoded event", err)
+		a.subHandlerReturn(ctx, log, err, msg, nil)
+		return event, false
 	}
-	log.DebugWithExtra("Decoded event", map[string]interface{}{
-		"Data": event.Data,
+
+	return event, true
+}
+
+func (a *App) processAddEvent(ctx context.Context, event adder.AddEvent) float64 {
+	log := logger.FromContext(ctx).WithAction("App.processAddEvent")
+	log.DebugWithExtra("Processing event", map[string]interface{}{
+		"Event": event,
 	})
-	result, err := a.processAddEvent(event)
 
-	a.subHandlerReturn(log, err, msg, result)
+	return event.A + event.B
+}
+
+// decimalArithmetic reports whether msg is processed in decimal arithmetic, its header being checked by validate
+func (a *App) decimalArithmetic(msg *nats.Msg) bool {
+	arithmetic, _ := types.ArithmeticOf(msg, a.config.App.Arithmetic.Mode)
+
+	return arithmetic == types.ArithmeticDecimal
 }
 
-func (a *App) processAddEvent(input types.InputEvent) (float64, error) {
-	log := logger.New(appID, "App.processAddEvent")
+// decimalAdd returns the sum of the operands of msg in decimal arithmetic, or replies with the reason
+// it cannot be computed
+func (a *App) decimalAdd(ctx context.Context, log *logger.Logger, msg *nats.Msg) (*decimal.Decimal, bool) {
+	event, ok := decodedEvent[adder.DecimalAddEvent](ctx, a, log, msg)
+	if !ok {
+		return nil, false
+	}
+
 	log.DebugWithExtra("Processing event", map[string]interface{}{
-		"Data": input.Data,
+		"Event": event,
 	})
 
-	var event adder.AddEvent
-	err := decoder.Decode(input.Data, &event)
-	if err != nil {
-		log.ErrorWithExtra("could not decode event", map[string]interface{}{"event": input}, err)
-		return 0, err
+	result := &decimal.Decimal{}
+	if _, err := a.decimals.Add(&result.Decimal, &event.A.Decimal, &event.B.Decimal); err != nil {
+		log.Error("Could not compute the sum", err)
+		a.subHandlerReturn(ctx, log, err, msg, nil)
+		return nil, false
 	}
 
-	return event.A + event.B, nil
+	return result, true
 }
@@ -1,15 +1,41 @@
 package v1
 
 import (
+	"time"
+
+	"github.com/ventive/go-mono-template/internal/types"
+	"github.com/ventive/go-mono-template/pkg/auth"
 	configparser "github.com/ventive/go-mono-template/pkg/config-parser"
+	"github.com/ventive/go-mono-template/pkg/decimal"
+	"github.com/ventive/go-mono-template/pkg/decoder"
 	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/metrics"
+	"github.com/ventive/go-mono-template/pkg/nats/breaker"
+	"github.com/ventive/go-mono-template/pkg/nats/faults"
+	"github.com/ventive/go-mono-template/pkg/nats/middleware"
+	"github.com/ventive/go-mono-template/pkg/tracing"
 )
 
 const (
-	defaultAppEnv      = "staging"
-	defaultLoggerLevel = "debug"
+	defaultAppEnv             = "staging"
+	defaultLoggerLevel        = "debug"
+	defaultTracingExporter    = tracing.ExporterOTLP
+	defaultTracingSampleRatio = 1
+	productionAppEnv          = "production"
+	defaultIdempotencyTTL     = 10 * time.Minute
 )
 
+// defaultMiddlewares run from the first to the last, the disabled ones are skipped
+var defaultMiddlewares = []string{
+	middleware.NameRecover,
+	middleware.NameTrace,
+	middleware.NameLog,
+	middleware.NameRateLimit,
+	middleware.NameAuth,
+	middleware.NameIdempotency,
+	middleware.NameDeadLetter,
+}
+
 type queuesConfig struct {
 	Publish struct {
 		Default string `mapstructure:"default"`
@@ -27,30 +53,79 @@ type natsConfig struct {
 	User string `mapstructure:"user"`
 	Pass string `mapstructure:"pass"`
 	TLS  struct {
-		Enabled bool   `mapstructure:"enabled"`
-		Cert    string `mapstructure:"cert"`
-		Key     string `mapstructure:"key"`
-		CA      string `mapstructure:"ca"`
+		Enabled        bool          `mapstructure:"enabled"`
+		Cert           string        `mapstructure:"cert"`
+		Key            string        `mapstructure:"key"`
+		CA             string        `mapstructure:"ca"`
+		ServerName     string        `mapstructure:"server_name"`
+		MinVersion     string        `mapstructure:"min_version"`
+		CipherSuites   []string      `mapstructure:"cipher_suites"`
+		HandshakeFirst bool          `mapstructure:"handshake_first"`
+		ReloadInterval time.Duration `mapstructure:"reload_interval"`
 	} `mapstructure:"tls"`
 }
 
+type cloudEventsConfig struct {
+	// Mode of the output messages of the inputs without CloudEvents envelope, structured or binary
+	Mode types.CloudEventsMode `mapstructure:"mode"`
+}
+
+type arithmeticConfig struct {
+	// Mode of the requests without arithmetic header, float or decimal
+	Mode    types.Arithmetic `mapstructure:"mode"`
+	Decimal decimal.Config   `mapstructure:"decimal"`
+}
+
+type recorderConfig struct {
+	File     string   `mapstructure:"file"`
+	Subjects []string `mapstructure:"subjects"`
+}
+
 type appConfig struct {
-	Env    string       `mapstructure:"env"`
-	Nats   natsConfig   `mapstructure:"nats"`
-	Queues queuesConfig `mapstructure:"queues"`
+	Env         string                       `mapstructure:"env"`
+	Nats        natsConfig                   `mapstructure:"nats"`
+	Queues      queuesConfig                 `mapstructure:"queues"`
+	Recorder    recorderConfig               `mapstructure:"recorder"`
+	Metrics     metrics.Config               `mapstructure:"metrics"`
+	CloudEvents cloudEventsConfig            `mapstructure:"cloud_events"`
+	Faults      faults.Config                `mapstructure:"faults"`
+	RateLimit   middleware.RateLimitConfig   `mapstructure:"rate_limit"`
+	Breaker     breaker.Config               `mapstructure:"circuit_breaker"`
+	Auth        auth.Config                  `mapstructure:"auth"`
+	Middleware  middleware.ChainConfig       `mapstructure:"middleware"`
+	Idempotency middleware.IdempotencyConfig `mapstructure:"idempotency"`
+	MessageLog  middleware.LogConfig         `mapstructure:"message_log"`
+	DeadLetter  middleware.DeadLetterConfig  `mapstructure:"dead_letter"`
+	Partition   middleware.PartitionConfig   `mapstructure:"partition"`
+	Versions    middleware.VersionConfig     `mapstructure:"versions"`
+	Shadow      middleware.ShadowConfig      `mapstructure:"shadow"`
+	Decoder     decoder.Options              `mapstructure:"decoder"`
+	// ValidateSchema validates the events against their JSON schema before decoding them
+	ValidateSchema bool             `mapstructure:"validate_schema"`
+	Arithmetic     arithmeticConfig `mapstructure:"arithmetic"`
 }
 
 type config struct {
-	App    appConfig     `mapstructure:"app"`
-	Logger logger.Config `mapstructure:"logger"`
+	App     appConfig      `mapstructure:"app"`
+	Logger  logger.Config  `mapstructure:"logger"`
+	Tracing tracing.Config `mapstructure:"tracing"`
 }
 
 func newConfig() (config, error) {
 	cfg := config{}
 
 	defaults := map[string]interface{}{
-		"app.env":      defaultAppEnv,
-		"logger.level": defaultLoggerLevel,
+		"app.env":                      defaultAppEnv,
+		"logger.level":                 defaultLoggerLevel,
+		"tracing.exporter":             defaultTracingExporter,
+		"tracing.sample_ratio":         defaultTracingSampleRatio,
+		"app.middleware.global":        defaultMiddlewares,
+		"app.idempotency.store":        middleware.IdempotencyStoreMemory,
+		"app.idempotency.ttl":          defaultIdempotencyTTL,
+		"app.dead_letter.max_attempts": middleware.DefaultDeadLetterMaxAttempts,
+		"app.cloud_events.mode":        types.CloudEventsStructured,
+		"app.metrics.addr":             metrics.DefaultAddr,
+		"app.arithmetic.mode":          types.ArithmeticFloat,
 	}
 
 	if err := configparser.Parse(configFile, &cfg, defaults); err != nil {
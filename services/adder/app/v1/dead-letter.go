@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/nats/deadletter"
+)
+
+func listDeadLetters(parentCtx context.Context) {
+	runDeadLetterCommand(parentCtx, "listDeadLetters", func(a *App, q *deadletter.Queue) error {
+		entries, err := q.List(a.ctx, dlqSequence, dlqLimit)
+		for _, e := range entries {
+			fmt.Printf("seq=%d stored_at=%s subject=%s attempts=%d error=%q\n",
+				e.Sequence, e.StoredAt.Format(time.RFC3339), e.Subject, e.Attempts, lastError(e.Errors))
+		}
+
+		return err
+	})
+}
+
+func inspectDeadLetter(parentCtx context.Context) {
+	runDeadLetterCommand(parentCtx, "inspectDeadLetter", func(a *App, q *deadletter.Queue) error {
+		entry, err := q.Get(a.ctx, dlqSequence)
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(entry)
+	})
+}
+
+func redriveDeadLetter(parentCtx context.Context) {
+	runDeadLetterCommand(parentCtx, "redriveDeadLetter", func(a *App, q *deadletter.Queue) error {
+		entry, err := q.Redrive(a.ctx, a.nats.PublishMsg, dlqSequence)
+		if err != nil {
+			return err
+		}
+
+		logger.New(appID, "redriveDeadLetter").Info(fmt.Sprintf("Dead letter %d re-driven to %s", entry.Sequence, entry.Subject))
+
+		return nil
+	})
+}
+
+// runDeadLetterCommand runs fn with the dead letter queue of the configured stream
+func runDeadLetterCommand(parentCtx context.Context, action string, fn func(a *App, q *deadletter.Queue) error) {
+	log := logger.New(appID, action)
+	cfg := initConfig(log)
+
+	app, err := New(parentCtx, cfg)
+	if err != nil {
+		log.Error("Unable to initialize adder", err)
+		return
+	}
+
+	if err = app.nats.Connect(); err != nil {
+		log.Error("Unable to connect to NATS", err)
+		return
+	}
+	defer app.nats.Close()
+
+	q, err := deadletter.NewQueue(app.ctx, app.nats.GetConn(), cfg.App.DeadLetter.Stream, cfg.App.DeadLetter.Subject)
+	if err != nil {
+		log.Error("Unable to open the dead letter stream", err)
+		return
+	}
+
+	if err = fn(app, q); err != nil {
+		log.Error("Dead letter command failed", err)
+	}
+}
+
+func lastError(errs []string) string {
+	if len(errs) == 0 {
+		return ""
+	}
+
+	return errs[len(errs)-1]
+}
@@ -1,13 +1,16 @@
 package v1
 
 import (
-	"encoding/json"
+	"context"
+	"strings"
 
+	"github.com/ventive/go-mono-template/internal/types"
 	"github.com/ventive/go-mono-template/pkg/logger"
 	"github.com/ventive/go-mono-template/pkg/nats"
+	"github.com/ventive/go-mono-template/pkg/nats/middleware"
 )
 
-func (a *App) publishError(requestHeaders map[string]string, errInput error) {
+func (a *App) publishError(input types.CloudEvent, requestHeaders map[string]string, errInput error) {
 	if errInput == nil {
 		return
 	}
@@ -18,28 +21,43 @@ func (a *App) publishError(requestHeaders map[string]string, errInput error) {
 		Message: errInput.Error(),
 	}
 
-	err := a.publishData(requestHeaders, a.config.App.Queues.Publish.Errors, natsError, nil)
+	err := a.publishData(input, requestHeaders, a.config.App.Queues.Publish.Errors, types.ErrorEventType, natsError, nil,
+		a.nats.PublishMsg)
 	if err != nil {
 		log.Error("error when publishing error msg", err)
 	}
 }
 
-func (a *App) publishData(requestHeaders map[string]string, subject string, data interface{}, withErr error) error {
+// publishData publishes data in a CloudEvent of eventType caused by input, in the mode of input,
+// else the configured one
+func (a *App) publishData(input types.CloudEvent, requestHeaders map[string]string, subject, eventType string,
+	data interface{}, withErr error, publish func(*nats.Msg) error) error {
 	log := logger.New(appID, "App.publishData")
 
 	if withErr != nil {
-		requestHeaders["X-Error"] = withErr.Error()
+		requestHeaders[nats.ErrorHeader] = withErr.Error()
 	}
 
-	var err error
-	natsMsg := nats.NewMsgWithHeaders(subject, requestHeaders)
-	if natsMsg.Data, err = json.Marshal(data); err != nil {
+	event, err := types.NewCloudEvent(a.eventSource(), eventType, data)
+	if err != nil {
 		log.Error("error when encoding output message data", err)
 
 		return err
 	}
+	event.CausationID = input.ID
+
+	mode := input.Mode
+	if mode == "" {
+		mode = a.config.App.CloudEvents.Mode
+	}
+	natsMsg := nats.NewMsgWithHeaders(subject, requestHeaders)
+	if err = event.Encode(natsMsg, mode); err != nil {
+		log.Error("error when encoding output message", err)
+
+		return err
+	}
 
-	if err = a.nats.PublishMsg(natsMsg); err != nil {
+	if err = publish(natsMsg); err != nil {
 		log.Error("error when publishing output msg", err)
 
 		return err
@@ -48,9 +66,14 @@ func (a *App) publishData(requestHeaders map[string]string, subject string, data
 	return nil
 }
 
-func (a *App) subHandlerReturn(log *logger.Logger, err error, msg *nats.Msg, response interface{}) {
+// subHandlerReturn publishes the response of msg through the middlewares of ctx and the error, if any
+func (a *App) subHandlerReturn(ctx context.Context, log *logger.Logger, err error, msg *nats.Msg, response interface{}) {
 	requestHeaders := make(map[string]string)
 	for k := range msg.Header {
+		// the credentials of the request are not forwarded
+		if strings.EqualFold(k, middleware.AuthorizationHeader) {
+			continue
+		}
 		requestHeaders[k] = msg.Header.Get(k)
 	}
 
@@ -59,9 +82,27 @@ func (a *App) subHandlerReturn(log *logger.Logger, err error, msg *nats.Msg, res
 		replySubject = msg.Reply
 	}
 
-	if err = a.publishData(requestHeaders, replySubject, response, err); err != nil {
-		log.Error("error when publishing output msg", err)
+	// the responses to the messages without envelope are enveloped as well
+	input, _ := types.ParseCloudEvent(msg)
+
+	respond := func(out *nats.Msg) error {
+		return middleware.Respond(ctx, a.nats.PublishMsg, out)
+	}
+	if publishErr := a.publishData(input, requestHeaders, replySubject, resultType, response, err, respond); publishErr != nil {
+		log.Error("error when publishing output msg", publishErr)
+	}
+
+	// the failures of the attempts retried by the dead letter middleware, or of the shadow handler, are not reported
+	if !middleware.WillRetry(ctx) && !middleware.IsShadow(ctx) {
+		a.publishError(input, requestHeaders, err)
+	}
+}
+
+// eventSource is the CloudEvents source of the published events
+func (a *App) eventSource() string {
+	if a.config.Logger.Source != "" {
+		return a.config.Logger.Source
 	}
 
-	a.publishError(requestHeaders, err)
+	return appID
 }
@@ -0,0 +1,109 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/nats/recorder"
+)
+
+func record(parentCtx context.Context) {
+	log := logger.New(appID, "record")
+	cfg := initConfig(log)
+
+	app, err := New(parentCtx, cfg)
+	if err != nil {
+		log.Error("Unable to initialize adder", err)
+		return
+	}
+
+	if err = app.record(recordFile); err != nil {
+		log.Error("Recording failed", err)
+	}
+}
+
+func replay(parentCtx context.Context) {
+	log := logger.New(appID, "replay")
+	cfg := initConfig(log)
+
+	app, err := New(parentCtx, cfg)
+	if err != nil {
+		log.Error("Unable to initialize adder", err)
+		return
+	}
+
+	if err = app.replay(recordFile, replaySpeed); err != nil {
+		log.Error("Replay failed", err)
+	}
+}
+
+// record writes the traffic of the configured subjects to file until the app is stopped.
+func (a *App) record(file string) error {
+	log := logger.New(appID, "App.record")
+	if file == "" {
+		file = a.config.App.Recorder.File
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	w, err := recorder.NewWriter(f)
+	if err != nil {
+		return errors.Join(err, f.Close())
+	}
+
+	if err = a.nats.Connect(); err != nil {
+		return errors.Join(err, f.Close())
+	}
+	defer a.nats.Close()
+
+	subjects := a.config.App.Recorder.Subjects
+	log.Info(fmt.Sprintf("Recording %s to %s", strings.Join(subjects, ", "), file))
+	count, err := recorder.Record(a.ctx, a.nats, subjects, w)
+	log.Info(fmt.Sprintf("Recorded %d messages", count))
+
+	return errors.Join(err, f.Close())
+}
+
+// replay publishes the messages recorded in file at the given speed.
+func (a *App) replay(file, speed string) error {
+	log := logger.New(appID, "App.replay")
+	if file == "" {
+		file = a.config.App.Recorder.File
+	}
+
+	s, err := recorder.ParseSpeed(speed)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	r, err := recorder.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	if err = a.nats.Connect(); err != nil {
+		return err
+	}
+	defer a.nats.Close()
+
+	log.Info(fmt.Sprintf("Replaying %s at %s speed", file, speed))
+	count, err := recorder.Replay(a.ctx, a.nats, r, s)
+	log.Info(fmt.Sprintf("Replayed %d messages", count))
+
+	return err
+}
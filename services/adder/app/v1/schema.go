@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ventive/go-mono-template/internal/types"
+	"github.com/ventive/go-mono-template/pkg/logger"
+)
+
+func printSchemas(_ context.Context) {
+	log := logger.New(appID, "printSchemas")
+
+	registry, err := types.Schemas()
+	if err != nil {
+		log.Error("Unable to generate the schemas", err)
+		return
+	}
+
+	if schemaDir != "" {
+		paths, err := registry.WriteFiles(schemaDir)
+		for _, path := range paths {
+			log.Info(fmt.Sprintf("Schema written to %s", path))
+		}
+		if err != nil {
+			log.Error("Unable to write the schemas", err)
+		}
+
+		return
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(registry); err != nil {
+		log.Error("Unable to print the schemas", err)
+	}
+}
@@ -1,8 +1,18 @@
 package v1
 
 import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/ventive/go-mono-template/internal/types"
+	"github.com/ventive/go-mono-template/internal/types/adder"
+	"github.com/ventive/go-mono-template/pkg/auth"
+	"github.com/ventive/go-mono-template/pkg/decimal"
+	"github.com/ventive/go-mono-template/pkg/decoder"
 	"github.com/ventive/go-mono-template/pkg/logger"
 	"github.com/ventive/go-mono-template/pkg/nats"
+	"github.com/ventive/go-mono-template/pkg/nats/deadletter"
 	"github.com/ventive/go-mono-template/pkg/nats/middleware"
 )
 
@@ -28,14 +38,29 @@ func (a *App) setupNats() error {
 }
 
 func (a *App) natsSubscribe() error {
-	middleware.LogSource = a.config.Logger.Source
-	middleware.LogEnv = a.config.App.Env
+	registry, err := a.middlewares()
+	if err != nil {
+		return err
+	}
+
+	handler, err := a.versionRouter()
+	if err != nil {
+		return err
+	}
 
-	var err error
+	validate, err := a.validate()
+	if err != nil {
+		return err
+	}
+
+	shadow, err := a.shadowChain()
+	if err != nil {
+		return err
+	}
 
 	queue := a.config.App.Queues.Subscribe.Queue
 	if queue != "" {
-		a.subscription, err = a.natsSubscribeTo(queue, a.addHandler)
+		a.subscription, err = a.natsSubscribeTo(registry, queue, validate.Extend(shadow), handler)
 		if err != nil {
 			return err
 		}
@@ -44,13 +69,242 @@ func (a *App) natsSubscribe() error {
 	return nil
 }
 
-func (a *App) natsSubscribeTo(queue string, handler func(*nats.Msg)) (*nats.Subscription, error) {
+// middlewares registers the middlewares that .config.yml can select, the disabled ones are nil
+func (a *App) middlewares() (*middleware.Registry, error) {
+	log := logger.New(appID, "App.middlewares")
+	registry := middleware.NewRegistry()
+
+	registry.Register(middleware.NameRecover, middleware.Adapt(middleware.Recover(middleware.RecoverConfig{
+		Source:        appID,
+		ErrorsSubject: a.config.App.Queues.Publish.Errors,
+		Publish:       a.nats.PublishMsg,
+	})))
+
+	logMessages, err := middleware.Log(a.config.Logger.Source, a.config.App.Env, a.config.App.MessageLog)
+	if err != nil {
+		log.Error("Error creating the log middleware", err)
+
+		return nil, err
+	}
+	registry.Register(middleware.NameLog, logMessages)
+
+	var trace, rateLimit, authorize middleware.ContextMiddleware
+	if a.config.Tracing.Enabled {
+		trace = middleware.TraceContext(a.config.App.Nats.Name)
+	}
+	if cfg := a.config.App.RateLimit; cfg.Enabled {
+		if cfg.Mode == middleware.RateLimitModeDelay && !a.config.App.Partition.Enabled {
+			log.Error("Error creating the rate limit middleware", middleware.ErrRateLimitDelayNotPartitioned)
+
+			return nil, middleware.ErrRateLimitDelayNotPartitioned
+		}
+		rateLimit = middleware.RateLimit(cfg, a.nats.PublishMsg)
+	}
+	if a.config.App.Auth.Enabled {
+		verifier, err := auth.NewVerifier(a.config.App.Auth)
+		if err != nil {
+			log.Error("Error loading auth keys", err)
+
+			return nil, err
+		}
+		authorize = middleware.Auth(verifier, a.config.App.Auth.Rules, a.nats.PublishMsg)
+	}
+	registry.Register(middleware.NameTrace, trace)
+	registry.Register(middleware.NameRateLimit, rateLimit)
+	registry.Register(middleware.NameAuth, authorize)
+
+	var idempotency middleware.ContextMiddleware
+	if a.config.App.Idempotency.Enabled {
+		store, err := a.idempotencyStore()
+		if err != nil {
+			log.Error("Error creating the idempotency store", err)
+
+			return nil, err
+		}
+		idempotency = middleware.Idempotency(store, a.nats.PublishMsg)
+	}
+	registry.Register(middleware.NameIdempotency, idempotency)
+
+	var deadLetter middleware.ContextMiddleware
+	if cfg := a.config.App.DeadLetter; cfg.Enabled {
+		// the stream must exist for the dead letters to be kept
+		if _, err := deadletter.NewQueue(a.ctx, a.nats.GetConn(), cfg.Stream, cfg.Subject); err != nil {
+			log.Error("Error creating the dead letter stream", err)
+
+			return nil, err
+		}
+		deadLetter = middleware.DeadLetter(appID, cfg, a.nats.PublishMsg)
+	}
+	registry.Register(middleware.NameDeadLetter, deadLetter)
+
+	return registry, nil
+}
+
+func (a *App) idempotencyStore() (middleware.IdempotencyStore, error) {
+	cfg := a.config.App.Idempotency
+	// a store without ttl would expire the memory entries at once and never the jetstream ones
+	if cfg.TTL <= 0 {
+		return nil, fmt.Errorf("%w: %s", middleware.ErrInvalidTTL, cfg.TTL)
+	}
+	if cfg.Store == middleware.IdempotencyStoreJetStream {
+		return middleware.NewKVIdempotencyStore(a.ctx, a.nats.GetConn(), cfg.Bucket, cfg.TTL)
+	}
+
+	return middleware.NewMemoryIdempotencyStore(cfg.TTL, cfg.MaxEntries), nil
+}
+
+// handlers returns the handlers that app.versions and app.shadow can select, by name
+func (a *App) handlers() map[string]middleware.Handler {
+	return map[string]middleware.Handler{
+		"add.v1": a.addHandler,
+		"add.v2": a.addHandlerV2,
+	}
+}
+
+// versionRouter returns the handler of the versions configured in app.versions, the v1 handler when disabled
+func (a *App) versionRouter() (middleware.Handler, error) {
+	cfg := a.config.App.Versions
+	if !cfg.Enabled {
+		return a.addHandler, nil
+	}
+
+	router, err := middleware.VersionRouter(cfg, a.handlers(), a.nats.PublishMsg)
+	if err != nil {
+		logger.New(appID, "App.versionRouter").Error("Error creating the version router", err)
+
+		return nil, err
+	}
+
+	return router, nil
+}
+
+// shadowChain mirrors the messages to the handler configured in app.shadow, it is empty when disabled
+func (a *App) shadowChain() (middleware.Chain, error) {
+	cfg := a.config.App.Shadow
+	if !cfg.Enabled {
+		return middleware.Chain{}, nil
+	}
+
+	shadow, ok := a.handlers()[cfg.Handler]
+	if !ok {
+		err := fmt.Errorf("%w: %q", middleware.ErrUnknownHandler, cfg.Handler)
+		logger.New(appID, "App.shadowChain").Error("Error creating the shadow middleware", err)
+
+		return middleware.Chain{}, err
+	}
+
+	// the envelopes of the responses have their own id and time
+	cfg.Payload = types.CloudEventData
+
+	return middleware.Chain{}.Append(middleware.NameShadow, middleware.Shadow(cfg, shadow)), nil
+}
+
+// validate decodes the events with the Validate middleware of their arithmetic, the requests with an unknown
+// arithmetic are rejected
+func (a *App) validate() (middleware.Chain, error) {
+	// the older versions of the events are migrated before being validated
+	versions, err := types.EventVersions()
+	if err != nil {
+		logger.New(appID, "App.validate").Error("Error loading the event versions", err)
+
+		return middleware.Chain{}, err
+	}
+
+	floatConfig, err := a.validateConfig(eventSchema, versions)
+	if err != nil {
+		return middleware.Chain{}, err
+	}
+	decimalConfig, err := a.validateConfig(decimalEventSchema, versions)
+	if err != nil {
+		return middleware.Chain{}, err
+	}
+	// the decimals are read by encoding/json, or by their hook from the intermediate map
+	if decimalConfig.Decode.DecodesMap() {
+		decimalConfig.Decode.Hooks = append(slices.Clone(decimalConfig.Decode.Hooks), decimal.DecodeHook())
+	}
+
+	floats := middleware.Validate[adder.AddEvent](floatConfig)
+	decimals := middleware.Validate[adder.DecimalAddEvent](decimalConfig)
+	validate := func(next middleware.Handler) middleware.Handler {
+		floatNext, decimalNext := floats(next), decimals(next)
+
+		return func(ctx context.Context, msg *nats.Msg) {
+			arithmetic, err := types.ArithmeticOf(msg, a.config.App.Arithmetic.Mode)
+			if err != nil {
+				log := logger.FromContext(ctx).WithAction("App.validate")
+				log.Error("Invalid arithmetic", err)
+				a.subHandlerReturn(ctx, log, err, msg, nil)
+				return
+			}
+
+			if arithmetic == types.ArithmeticDecimal {
+				decimalNext(ctx, msg)
+				return
+			}
+			floatNext(ctx, msg)
+		}
+	}
+
+	return middleware.Chain{}.Append(middleware.NameValidate, validate), nil
+}
+
+// validateConfig replies to the invalid events of the schema the same way as subHandlerReturn,
+// their older versions are migrated with versions
+func (a *App) validateConfig(schema string, versions *types.Versions) (middleware.ValidateConfig, error) {
+	log := logger.New(appID, "App.validateConfig")
+	cfg := middleware.ValidateConfig{
+		Decode:        a.config.App.Decoder,
+		Subject:       a.config.App.Queues.Publish.Default,
+		ErrorsSubject: a.config.App.Queues.Publish.Errors,
+		Publish:       a.nats.PublishMsg,
+	}
+
+	cfg.Data = versions.Data(schema)
+
+	if _, err := decoder.Translator(cfg.Decode.Locale); err != nil {
+		log.Error("Error loading the validation messages", err)
+
+		return cfg, err
+	}
+
+	if a.config.App.ValidateSchema {
+		schemas, err := types.Schemas()
+		if err == nil {
+			cfg.Schema, err = schemas.Get(schema)
+		}
+		if err != nil {
+			log.Error("Error loading the event schema", err)
+
+			return cfg, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// natsSubscribeTo subscribes handler to queue through the middlewares configured for queue, then inner
+func (a *App) natsSubscribeTo(registry *middleware.Registry, queue string, inner middleware.Chain,
+	handler middleware.Handler) (*nats.Subscription, error) {
 	log := logger.New(appID, "App.natsSubscribeTo")
 
-	log.Info("subscribing to " + queue)
+	chain, err := registry.ChainFor(a.config.App.Middleware, queue)
+	if err != nil {
+		log.Error("Error building the middleware chain of "+queue, err)
+
+		return nil, err
+	}
+	chain = chain.Extend(inner)
+
+	log.Info("subscribing to " + queue + " through " + chain.String())
+
+	msgHandler := middleware.ToMsgHandler(a.ctx, appID, chain.Then(handler))
+	if cfg := a.config.App.Partition; cfg.Enabled {
+		log.Info(fmt.Sprintf("processing %s in %d partitions", queue, cfg.Partitions))
+		a.partitioner = middleware.NewPartitioner(appID, cfg, msgHandler)
+		msgHandler = a.partitioner.Handle
+	}
 
-	sub, err := a.nats.QueueSubscribe(queue, a.config.App.Nats.Name,
-		middleware.UseMiddleware(handler, middleware.Log))
+	sub, err := a.nats.QueueSubscribe(queue, a.config.App.Nats.Name, msgHandler)
 	if err != nil {
 		log.Error("Error subscribing to "+queue, err)
 
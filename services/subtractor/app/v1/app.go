@@ -3,8 +3,14 @@ package v1
 import (
 	"context"
 
+	"github.com/cockroachdb/apd/v3"
+	"github.com/ventive/go-mono-template/internal/types"
 	"github.com/ventive/go-mono-template/pkg/logger"
 	"github.com/ventive/go-mono-template/pkg/nats"
+	"github.com/ventive/go-mono-template/pkg/nats/breaker"
+	"github.com/ventive/go-mono-template/pkg/nats/faults"
+	"github.com/ventive/go-mono-template/pkg/nats/middleware"
+	"github.com/ventive/go-mono-template/pkg/tracing"
 )
 
 type App struct {
@@ -13,6 +19,9 @@ type App struct {
 	config       config
 	nats         nats.Client
 	subscription *nats.Subscription
+	partitioner  *middleware.Partitioner
+	// decimals computes the results in decimal arithmetic
+	decimals *apd.Context
 }
 
 func New(parentCtx context.Context, cfg config) (*App, error) {
@@ -25,6 +34,19 @@ func New(parentCtx context.Context, cfg config) (*App, error) {
 	}
 	log.Info("Setting up the app dependencies...")
 
+	if _, err := types.ParseArithmetic(string(cfg.App.Arithmetic.Mode)); err != nil {
+		log.Error("Invalid arithmetic mode", err)
+
+		return nil, err
+	}
+	decimals, err := cfg.App.Arithmetic.Decimal.Context()
+	if err != nil {
+		log.Error("Invalid decimal arithmetic", err)
+
+		return nil, err
+	}
+	app.decimals = decimals
+
 	log.Info("Setting up NATS client")
 	app.nats = nats.NewClient(nats.Config{
 		URL:  cfg.App.Nats.URL,
@@ -32,12 +54,35 @@ func New(parentCtx context.Context, cfg config) (*App, error) {
 		User: cfg.App.Nats.User,
 		Pass: cfg.App.Nats.Pass,
 		TLS: nats.TLSConfig{
-			Enabled: cfg.App.Nats.TLS.Enabled,
-			Cert:    cfg.App.Nats.TLS.Cert,
-			Key:     cfg.App.Nats.TLS.Key,
-			CA:      cfg.App.Nats.TLS.CA,
+			Enabled:        cfg.App.Nats.TLS.Enabled,
+			Cert:           cfg.App.Nats.TLS.Cert,
+			Key:            cfg.App.Nats.TLS.Key,
+			CA:             cfg.App.Nats.TLS.CA,
+			ServerName:     cfg.App.Nats.TLS.ServerName,
+			MinVersion:     cfg.App.Nats.TLS.MinVersion,
+			CipherSuites:   cfg.App.Nats.TLS.CipherSuites,
+			HandshakeFirst: cfg.App.Nats.TLS.HandshakeFirst,
+			ReloadInterval: cfg.App.Nats.TLS.ReloadInterval,
 		},
 	})
+
+	if cfg.App.Faults.Enabled {
+		if cfg.App.Env == productionAppEnv {
+			log.Warn("Fault injection is not allowed in production, ignoring it")
+		} else {
+			log.Warn("Fault injection enabled on the NATS client")
+			app.nats = faults.NewClient(app.nats, cfg.App.Faults)
+		}
+	}
+
+	if cfg.App.Breaker.Enabled {
+		app.nats = breaker.NewClient(app.nats, cfg.App.Breaker)
+	}
+
+	if cfg.Tracing.Enabled {
+		app.nats = tracing.WrapClient(app.nats)
+	}
+
 	return app, nil
 }
 
@@ -83,6 +128,11 @@ func (a *App) cleanup() {
 		}, err)
 	}
 
+	if a.partitioner != nil {
+		log.Info("Partitions: waiting for the queued messages")
+		a.partitioner.Close()
+	}
+
 	log.Info("Closing NATS connection")
 	a.nats.Close()
 
@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ventive/go-mono-template/internal/types"
+	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/nats"
+	"github.com/ventive/go-mono-template/pkg/nats/middleware"
+)
+
+// publishClient records the published messages, the other methods of nats.Client are not implemented
+type publishClient struct {
+	nats.Client
+	mu   sync.Mutex
+	msgs []*nats.Msg
+}
+
+func (c *publishClient) PublishMsg(msg *nats.Msg) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.msgs = append(c.msgs, msg)
+
+	return nil
+}
+
+// published returns the number of messages published to subject
+func (c *publishClient) published(subject string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for _, msg := range c.msgs {
+		if msg.Subject == subject {
+			n++
+		}
+	}
+
+	return n
+}
+
+func newPublishTestApp() (*App, *publishClient) {
+	client := &publishClient{}
+	cfg := config{}
+	cfg.App.Queues.Publish.Default = "results.unit-tests"
+	cfg.App.Queues.Publish.Errors = "errors.unit-tests"
+	cfg.App.CloudEvents.Mode = types.CloudEventsStructured
+
+	return &App{config: cfg, nats: client}, client
+}
+
+func TestSubHandlerReturn(t *testing.T) {
+	errFailed := errors.New("failed")
+	log := logger.New("unit-tests", "TestSubHandlerReturn")
+
+	t.Run("publishesHandlerError", func(t *testing.T) {
+		a, client := newPublishTestApp()
+		a.subHandlerReturn(context.Background(), log, errFailed, nats.NewMsg("unit-tests"), nil)
+
+		assert.Equal(t, 1, client.published("results.unit-tests"))
+		assert.Equal(t, 1, client.published("errors.unit-tests"))
+	})
+
+	t.Run("doesNotForwardAuthorization", func(t *testing.T) {
+		a, client := newPublishTestApp()
+		msg := nats.NewMsgWithHeaders("unit-tests", map[string]string{middleware.AuthorizationHeader: "Bearer token"})
+		a.subHandlerReturn(context.Background(), log, errFailed, msg, nil)
+
+		assert.Len(t, client.msgs, 2)
+		for _, out := range client.msgs {
+			assert.Empty(t, out.Header.Get(middleware.AuthorizationHeader))
+		}
+	})
+
+	t.Run("publishesLastAttemptErrorOnly", func(t *testing.T) {
+		a, client := newPublishTestApp()
+		deadLetter := middleware.DeadLetter("unit-tests", middleware.DeadLetterConfig{
+			Subject:     "dead-letters.unit-tests",
+			MaxAttempts: 3,
+		}, client.PublishMsg)
+		attempts := 0
+		handler := deadLetter(func(ctx context.Context, msg *nats.Msg) {
+			attempts++
+			a.subHandlerReturn(ctx, log, errFailed, msg, nil)
+		})
+
+		handler(context.Background(), nats.NewMsg("unit-tests"))
+
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, 1, client.published("results.unit-tests"))
+		assert.Equal(t, 1, client.published("errors.unit-tests"))
+		assert.Equal(t, 1, client.published("dead-letters.unit-tests"))
+	})
+
+	t.Run("doesNotPublishShadowError", func(t *testing.T) {
+		a, client := newPublishTestApp()
+		done := make(chan struct{})
+		handler := middleware.Shadow(middleware.ShadowConfig{}, func(ctx context.Context, msg *nats.Msg) {
+			defer close(done)
+			a.subHandlerReturn(ctx, log, errFailed, msg, nil)
+		})(func(ctx context.Context, msg *nats.Msg) {
+			a.subHandlerReturn(ctx, log, nil, msg, 1)
+		})
+
+		handler(context.Background(), nats.NewMsg("unit-tests"))
+		<-done
+
+		assert.Equal(t, 1, client.published("results.unit-tests"))
+		assert.Equal(t, 0, client.published("errors.unit-tests"))
+	})
+}
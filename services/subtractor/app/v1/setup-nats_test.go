@@ -0,0 +1,25 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ventive/go-mono-template/internal/types"
+	"github.com/ventive/go-mono-template/internal/types/subtractor"
+	"github.com/ventive/go-mono-template/pkg/nats"
+)
+
+func TestValidateConfig(t *testing.T) {
+	a, _ := newPublishTestApp()
+	versions := types.NewVersions()
+	assert.Nil(t, versions.Register(decimalEventSchema, subtractor.DecimalSubtractEvent{}))
+
+	cfg, err := a.validateConfig(decimalEventSchema, versions)
+	assert.Nil(t, err)
+
+	msg := nats.NewMsgWithHeaders("unit-tests", map[string]string{types.CloudEventsHeaderPrefix + "specversion": "1.0"})
+	msg.Data = []byte(`{"a":"0.1","b":"0.2"}`)
+	data, err := cfg.Data(msg)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"a":"0.1","b":"0.2"}`, string(data))
+}
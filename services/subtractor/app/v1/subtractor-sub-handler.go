@@ -1,44 +1,121 @@
 package v1
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 
 	"github.com/ventive/go-mono-template/internal/types"
 	"github.com/ventive/go-mono-template/internal/types/subtractor"
-	"github.com/ventive/go-mono-template/pkg/decoder"
+	"github.com/ventive/go-mono-template/pkg/decimal"
 	"github.com/ventive/go-mono-template/pkg/logger"
 	"github.com/ventive/go-mono-template/pkg/nats"
+	"github.com/ventive/go-mono-template/pkg/nats/middleware"
 )
 
-func (a *App) subtractHandler(msg *nats.Msg) {
-	log := logger.New(appID, "App.subtractHandler")
+// subtractHandler replies with the result as a bare number, or a string in decimal arithmetic
+func (a *App) subtractHandler(ctx context.Context, msg *nats.Msg) {
+	log := logger.FromContext(ctx).WithAction("App.subtractHandler")
 	log.Info("New Event")
-	var event types.InputEvent
 
-	err := json.Unmarshal(msg.Data, &event)
+	if a.decimalArithmetic(msg) {
+		if result, ok := a.decimalSubtract(ctx, log, msg); ok {
+			a.subHandlerReturn(ctx, log, nil, msg, result)
+		}
+		return
+	}
+
+	event, ok := decodedEvent[subtractor.SubtractEvent](ctx, a, log, msg)
+	if !ok {
+		return
+	}
+
+	a.subHandlerReturn(ctx, log, nil, msg, a.processSubtractEvent(ctx, event))
+}
+
+// subtractHandlerV2 replies with the result in a subtractor.SubtractResult object,
+// or a subtractor.DecimalSubtractResult in decimal arithmetic
+func (a *App) subtractHandlerV2(ctx context.Context, msg *nats.Msg) {
+	log := logger.FromContext(ctx).WithAction("App.subtractHandlerV2")
+	log.Info("New Event")
+
+	if a.decimalArithmetic(msg) {
+		if result, ok := a.decimalSubtract(ctx, log, msg); ok {
+			a.subHandlerReturn(ctx, log, nil, msg, subtractor.DecimalSubtractResult{Result: result})
+		}
+		return
+	}
+
+	event, ok := decodedEvent[subtractor.SubtractEvent](ctx, a, log, msg)
+	if !ok {
+		return
+	}
+
+	a.subHandlerReturn(ctx, log, nil, msg, subtractor.SubtractResult{Result: a.processSubtractEvent(ctx, event)})
+}
+
+// decodedEvent returns the event of msg, or replies with the reason it cannot be processed
+func decodedEvent[T any](ctx context.Context, a *App, log *logger.Logger, msg *nats.Msg) (T, bool) {
+	var event T
+
+	// the caller is no longer waiting for the result
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		log.Warn("Deadline exceeded, skipping event")
+		a.subHandlerReturn(ctx, log, ctx.Err(), msg, nil)
+		return event, false
+	}
+
+	// the messages without envelope are still accepted
+	if _, err := types.ParseCloudEvent(msg); err != nil && !errors.Is(err, types.ErrNotCloudEvent) {
+		log.Error("Invalid CloudEvent", err)
+		a.subHandlerReturn(ctx, log, err, msg, nil)
+		return event, false
+	}
+
+	// decoded and validated by the validate middleware
+	event, err := middleware.Decoded[T](ctx)
 	if err != nil {
-		log.Error("Could not decode event", err)
+		log.Error("Could not get the decoded event", err)
+		a.subHandlerReturn(ctx, log, err, msg, nil)
+		return event, false
 	}
-	log.DebugWithExtra("Decoded event", map[string]interface{}{
-		"Data": event.Data,
+
+	return event, true
+}
+
+func (a *App) processSubtractEvent(ctx context.Context, event subtractor.SubtractEvent) float64 {
+	log := logger.FromContext(ctx).WithAction("App.processSubtractEvent")
+	log.DebugWithExtra("Processing event", map[string]interface{}{
+		"Event": event,
 	})
-	result, err := a.processSubtractEvent(event)
 
-	a.subHandlerReturn(log, err, msg, result)
+	return event.A - event.B
+}
+
+// decimalArithmetic reports whether msg is processed in decimal arithmetic, its header being checked by validate
+func (a *App) decimalArithmetic(msg *nats.Msg) bool {
+	arithmetic, _ := types.ArithmeticOf(msg, a.config.App.Arithmetic.Mode)
+
+	return arithmetic == types.ArithmeticDecimal
 }
 
-func (a *App) processSubtractEvent(input types.InputEvent) (float64, error) {
-	log := logger.New(appID, "App.processSubtractEvent")
+// decimalSubtract returns the difference of the operands of msg in decimal arithmetic, or replies with the reason
+// it cannot be computed
+func (a *App) decimalSubtract(ctx context.Context, log *logger.Logger, msg *nats.Msg) (*decimal.Decimal, bool) {
+	event, ok := decodedEvent[subtractor.DecimalSubtractEvent](ctx, a, log, msg)
+	if !ok {
+		return nil, false
+	}
+
 	log.DebugWithExtra("Processing event", map[string]interface{}{
-		"Data": input.Data,
+		"Event": event,
 	})
 
-	var event subtractor.SubtractEvent
-	err := decoder.Decode(input.Data, &event)
-	if err != nil {
-		log.ErrorWithExtra("could not decode event", map[string]interface{}{"event": input}, err)
-		return 0, err
+	result := &decimal.Decimal{}
+	if _, err := a.decimals.Sub(&result.Decimal, &event.A.Decimal, &event.B.Decimal); err != nil {
+		log.Error("Could not compute the difference", err)
+		a.subHandlerReturn(ctx, log, err, msg, nil)
+		return nil, false
 	}
 
-	return event.A - event.B, nil
+	return result, true
 }
@@ -5,20 +5,51 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/ventive/go-mono-template/internal/types/subtractor"
 	"github.com/ventive/go-mono-template/pkg/cli"
 	"github.com/ventive/go-mono-template/pkg/logger"
+	"github.com/ventive/go-mono-template/pkg/metrics"
+	"github.com/ventive/go-mono-template/pkg/tracing"
 	"github.com/ventive/go-mono-template/pkg/version"
 )
 
 const appID = "subtracotor"
 
-var configFile string
+// eventSchema is the name of the events, in types.Schemas and types.EventVersions
+const eventSchema = "subtractor.SubtractEvent"
+
+// decimalEventSchema is the name of the events in decimal arithmetic
+const decimalEventSchema = "subtractor.DecimalSubtractEvent"
+
+// resultType is the CloudEvents type of the responses
+const resultType = subtractor.SubtractResultType
+
+var (
+	configFile  string
+	recordFile  string
+	replaySpeed string
+	dlqSequence uint64
+	dlqLimit    int
+	schemaDir   string
+)
 
 func Run(ctx context.Context) error {
 	cli.Init(appID, "subtractor service")
 	_ = cli.AddCommand("version", "Get the application version and Git commit SHA", logVersionDetails)
 	_ = cli.AddCommand("start", "Start the service", start)
+	_ = cli.AddCommand("record", "Record the traffic of the configured NATS subjects to a file", record)
+	_ = cli.AddCommand("replay", "Replay a recorded traffic file", replay)
+	_ = cli.AddCommand("dlq", "Manage the dead letters", nil)
+	_ = cli.AddSubCommand("dlq", "list", "List the dead letters", listDeadLetters)
+	_ = cli.AddSubCommand("dlq", "inspect", "Show a dead letter", inspectDeadLetter)
+	_ = cli.AddSubCommand("dlq", "redrive", "Publish a dead letter again to its original subject", redriveDeadLetter)
+	_ = cli.AddCommand("schema", "Print the JSON schemas of the events", printSchemas)
 	cli.AssignStringFlag(&configFile, "config", "", "config file (default is ./.config.yaml)")
+	cli.AssignStringFlag(&recordFile, "file", "", "recording file (default is app.recorder.file)")
+	cli.AssignStringFlag(&replaySpeed, "speed", "original", "replay speed: original, max or a multiplier like 2 or 0.5")
+	cli.AssignUint64Flag(&dlqSequence, "seq", 0, "dead letter sequence to inspect or redrive, or the first one to list")
+	cli.AssignIntFlag(&dlqLimit, "limit", 20, "maximum number of dead letters listed, 0 for all")
+	cli.AssignStringFlag(&schemaDir, "out", "", "directory receiving one file per schema, instead of printing them")
 
 	return cli.Run(ctx)
 }
@@ -26,15 +57,29 @@ func Run(ctx context.Context) error {
 func start(parentCtx context.Context) {
 	log := logger.New(appID, "start")
 
-	cfg, err := newConfig()
+	cfg := initConfig(log)
+
+	shutdownTracing, err := tracing.Init(parentCtx, cfg.Logger.Source, cfg.Tracing)
 	if err != nil {
-		log.Error("Unable to initialize config", err)
+		log.Error("Unable to initialize tracing", err)
+	} else {
+		defer func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				log.Error("Unable to flush traces", err)
+			}
+		}()
 	}
 
-	logger.Init(logger.Config{
-		Level:  cfg.Logger.Level,
-		Format: cfg.Logger.Format,
-	})
+	shutdownMetrics, err := metrics.Serve(cfg.App.Metrics)
+	if err != nil {
+		log.Error("Unable to serve the metrics", err)
+	} else {
+		defer func() {
+			if err := shutdownMetrics(context.Background()); err != nil {
+				log.Error("Unable to stop the metrics server", err)
+			}
+		}()
+	}
 
 	ctx, cancelFunc := context.WithCancel(parentCtx)
 	app, err := New(ctx, cfg)
@@ -60,6 +105,20 @@ func start(parentCtx context.Context) {
 	wg.Wait()
 }
 
+func initConfig(log *logger.Logger) config {
+	cfg, err := newConfig()
+	if err != nil {
+		log.Error("Unable to initialize config", err)
+	}
+
+	logger.Init(logger.Config{
+		Level:  cfg.Logger.Level,
+		Format: cfg.Logger.Format,
+	})
+
+	return cfg
+}
+
 func logVersionDetails(_ context.Context) {
 	log := logger.New(appID, "logVersionDetails")
 	log.Info(fmt.Sprintf("AppVersion=%s, GitCommit=%s", version.AppVersion, version.GitCommit))